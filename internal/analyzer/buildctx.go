@@ -0,0 +1,222 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+var errInvalidPlatform = errors.New("invalid platform, want GOOS/GOARCH")
+
+// buildContext is a go/build context files are matched against. Name is
+// the platform the context stands for in union mode and empty otherwise.
+type buildContext struct {
+	name string
+	ctx  build.Context
+}
+
+// newBuildContexts returns the build contexts selected by opts: one per
+// entry of opts.Platforms, or a single context built from GOOS, GOARCH and
+// Tags, falling back to the defaults of the running toolchain.
+func newBuildContexts(opts Options) ([]buildContext, error) {
+	tracer.Enter("analyzer.newBuildContexts")
+
+	if len(opts.Platforms) == 0 {
+		ctx := build.Default
+		if opts.GOOS != "" {
+			ctx.GOOS = opts.GOOS
+		}
+		if opts.GOARCH != "" {
+			ctx.GOARCH = opts.GOARCH
+		}
+		ctx.BuildTags = opts.Tags
+
+		tracer.ExitSuccess("analyzer.newBuildContexts")
+		return []buildContext{{ctx: ctx}}, nil
+	}
+
+	contexts := make([]buildContext, 0, len(opts.Platforms))
+	for _, platform := range opts.Platforms {
+		goos, goarch, ok := strings.Cut(platform, "/")
+		if !ok || goos == "" || goarch == "" {
+			err := fmt.Errorf("%w: %q", errInvalidPlatform, platform)
+			tracer.ExitError("analyzer.newBuildContexts", err)
+			return nil, err
+		}
+
+		ctx := build.Default
+		ctx.GOOS = goos
+		ctx.GOARCH = goarch
+		ctx.BuildTags = opts.Tags
+
+		contexts = append(contexts, buildContext{name: platform, ctx: ctx})
+	}
+
+	tracer.ExitSuccess("analyzer.newBuildContexts")
+	return contexts, nil
+}
+
+// matchFile reports whether filename is part of the build in at least one
+// of the analyzer's build contexts, honoring //go:build lines and
+// _GOOS/_GOARCH file name suffixes. In union mode it also returns the
+// platforms the file belongs to.
+func (a *GoAnalyzer) matchFile(filename string) (bool, []string, error) {
+	tracer.Enter("analyzer.GoAnalyzer.matchFile")
+
+	dir, name := filepath.Split(filename)
+
+	var matched bool
+	var platforms []string
+
+	for _, bc := range a.contexts {
+		ok, err := bc.ctx.MatchFile(dir, name)
+		if err != nil {
+			tracer.ExitError("analyzer.GoAnalyzer.matchFile", err)
+			return false, nil, fmt.Errorf("failed to match build constraints of %s: %w", filename, err)
+		}
+		if !ok {
+			continue
+		}
+
+		matched = true
+		if bc.name != "" {
+			platforms = append(platforms, bc.name)
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.matchFile")
+	return matched, platforms, nil
+}
+
+// mergePlatforms returns the sorted union of two platform lists.
+func mergePlatforms(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+
+	set := make(map[string]bool, len(a)+len(b))
+	for _, p := range a {
+		set[p] = true
+	}
+	for _, p := range b {
+		set[p] = true
+	}
+
+	merged := make([]string, 0, len(set))
+	for p := range set {
+		merged = append(merged, p)
+	}
+	sort.Strings(merged)
+
+	return merged
+}
+
+// addType records a type declaration in types. A declaration of the same
+// type in another platform-specific file is merged into the existing one.
+func addType(types map[string]*TypeInfo, id string, typeInfo *TypeInfo) {
+	existing, exists := types[id]
+	if !exists {
+		types[id] = typeInfo
+		return
+	}
+
+	existing.Platforms = mergePlatforms(existing.Platforms, typeInfo.Platforms)
+	existing.Fields = mergeFields(existing.Fields, typeInfo.Fields)
+	existing.Embeds = mergeFields(existing.Embeds, typeInfo.Embeds)
+	existing.Instances = mergeInstances(existing.Instances, typeInfo.Instances)
+}
+
+// addFunction records a function declaration in functions, merging the
+// calls of platform-specific variants.
+func addFunction(functions map[string]*FunctionInfo, id string, funcInfo *FunctionInfo) {
+	existing, exists := functions[id]
+	if !exists {
+		functions[id] = funcInfo
+		return
+	}
+
+	existing.Platforms = mergePlatforms(existing.Platforms, funcInfo.Platforms)
+	existing.Calls = mergeCalls(existing.Calls, funcInfo.Calls)
+	existing.Refs = mergeCalls(existing.Refs, funcInfo.Refs)
+	existing.BodyTypes = mergeFields(existing.BodyTypes, funcInfo.BodyTypes)
+	existing.Instances = mergeInstances(existing.Instances, funcInfo.Instances)
+	existing.VarRefs = mergeVarRefs(existing.VarRefs, funcInfo.VarRefs)
+	existing.ChanOps = mergeChanOps(existing.ChanOps, funcInfo.ChanOps)
+}
+
+// addMethod records a method declaration in methods, merging the calls of
+// platform-specific variants.
+func addMethod(methods map[string]*MethodInfo, id string, methodInfo *MethodInfo) {
+	existing, exists := methods[id]
+	if !exists {
+		methods[id] = methodInfo
+		return
+	}
+
+	existing.Platforms = mergePlatforms(existing.Platforms, methodInfo.Platforms)
+	existing.Calls = mergeCalls(existing.Calls, methodInfo.Calls)
+	existing.Refs = mergeCalls(existing.Refs, methodInfo.Refs)
+	existing.BodyTypes = mergeFields(existing.BodyTypes, methodInfo.BodyTypes)
+	existing.Instances = mergeInstances(existing.Instances, methodInfo.Instances)
+	existing.VarRefs = mergeVarRefs(existing.VarRefs, methodInfo.VarRefs)
+	existing.ChanOps = mergeChanOps(existing.ChanOps, methodInfo.ChanOps)
+}
+
+func mergeFields(existing, added []FieldInfo) []FieldInfo {
+	for _, field := range added {
+		found := false
+		for _, e := range existing {
+			if e == field {
+				found = true
+				break
+			}
+		}
+		if !found {
+			existing = append(existing, field)
+		}
+	}
+
+	return existing
+}
+
+// mergeCalls appends the calls of added that existing does not record yet.
+// Type-checked union analysis loads shared files once per platform, so
+// most calls arrive more than once.
+func mergeCalls(existing, added []CallInfo) []CallInfo {
+	seen := make(map[string]bool, len(existing))
+	for _, call := range existing {
+		seen[callKey(call)] = true
+	}
+
+	for _, call := range added {
+		key := callKey(call)
+		if !seen[key] {
+			seen[key] = true
+			existing = append(existing, call)
+		}
+	}
+
+	return existing
+}
+
+func callKey(call CallInfo) string {
+	return fmt.Sprintf("%d:%s:%s:%s:%s", call.Line, call.Kind, call.Receiver, call.Target, call.Resolved)
+}
+
+// typedBuildEnv returns the go command environment and build flags that
+// select bc when loading packages.
+func typedBuildEnv(bc buildContext) ([]string, []string) {
+	env := []string{"GOOS=" + bc.ctx.GOOS, "GOARCH=" + bc.ctx.GOARCH}
+
+	var flags []string
+	if len(bc.ctx.BuildTags) > 0 {
+		flags = append(flags, "-tags="+strings.Join(bc.ctx.BuildTags, ","))
+	}
+
+	return env, flags
+}
@@ -0,0 +1,254 @@
+package analyzer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// cacheVersion must change whenever the extraction logic or the cached
+// structures change, so that stale entries are never reused.
+const cacheVersion = "11"
+
+const cacheEntrySuffix = ".gob"
+
+// cacheEntry is the on-disk form of a fileResult.
+type cacheEntry struct {
+	Package   *PackageInfo
+	Types     map[string]*TypeInfo
+	Functions map[string]*FunctionInfo
+	Methods   map[string]*MethodInfo
+	Vars      map[string]*VarInfo
+}
+
+// fileCache stores per-file extraction results in a directory. Entries are
+// keyed by the file content, the file location, the analyzer version and
+// the build settings that selected the file.
+type fileCache struct {
+	dir string
+}
+
+func (a *GoAnalyzer) cacheKey(filename string, src []byte, platforms []string) string {
+	h := sha256.New()
+
+	pkgPath, mod := a.packagePath(filepath.Dir(filename))
+	modulePath := ""
+	if mod != nil {
+		modulePath = mod.Path
+	}
+
+	for _, part := range []string{
+		cacheVersion,
+		runtime.Version(),
+		pkgPath,
+		modulePath,
+		filename,
+		strings.Join(platforms, ","),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(src)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *fileCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+cacheEntrySuffix)
+}
+
+// load returns the cached result for key. A hit refreshes the entry's
+// modification time, which PruneCache uses to find unused entries.
+func (c *fileCache) load(key string) (*fileResult, bool) {
+	tracer.Enter("analyzer.fileCache.load")
+
+	entryPath := c.path(key)
+	data, err := os.ReadFile(entryPath)
+	if err != nil {
+		tracer.ExitSuccess("analyzer.fileCache.load")
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry); err != nil {
+		tracer.ExitSuccess("analyzer.fileCache.load")
+		return nil, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(entryPath, now, now)
+
+	result := &fileResult{
+		pkg:       entry.Package,
+		types:     entry.Types,
+		functions: entry.Functions,
+		methods:   entry.Methods,
+		vars:      entry.Vars,
+	}
+	if result.types == nil {
+		result.types = make(map[string]*TypeInfo)
+	}
+	if result.functions == nil {
+		result.functions = make(map[string]*FunctionInfo)
+	}
+	if result.methods == nil {
+		result.methods = make(map[string]*MethodInfo)
+	}
+	if result.vars == nil {
+		result.vars = make(map[string]*VarInfo)
+	}
+
+	tracer.ExitSuccess("analyzer.fileCache.load")
+	return result, true
+}
+
+// store writes result under key. The entry is written to a temporary file
+// first and renamed, so concurrent readers never see partial entries.
+func (c *fileCache) store(key string, result *fileResult) error {
+	tracer.Enter("analyzer.fileCache.store")
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(cacheEntry{
+		Package:   result.pkg,
+		Types:     result.types,
+		Functions: result.functions,
+		Methods:   result.methods,
+		Vars:      result.vars,
+	})
+	if err != nil {
+		tracer.ExitError("analyzer.fileCache.store", err)
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	entryPath := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(entryPath), 0o755); err != nil {
+		tracer.ExitError("analyzer.fileCache.store", err)
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(entryPath), key+".*.tmp")
+	if err != nil {
+		tracer.ExitError("analyzer.fileCache.store", err)
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+
+	_, writeErr := tmp.Write(buf.Bytes())
+	closeErr := tmp.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		_ = os.Remove(tmp.Name())
+		tracer.ExitError("analyzer.fileCache.store", err)
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), entryPath); err != nil {
+		_ = os.Remove(tmp.Name())
+		tracer.ExitError("analyzer.fileCache.store", err)
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	tracer.ExitSuccess("analyzer.fileCache.store")
+	return nil
+}
+
+// parseFileCached returns the extraction result of a file from the cache,
+// parsing and caching it on a miss.
+func (a *GoAnalyzer) parseFileCached(task fileTask) (*fileResult, error) {
+	tracer.Enter("analyzer.GoAnalyzer.parseFileCached")
+
+	if a.cache == nil {
+		result, err := a.parseFile(task.path, nil, task.platforms)
+		if err != nil {
+			tracer.ExitError("analyzer.GoAnalyzer.parseFileCached", err)
+			return nil, err
+		}
+		tracer.ExitSuccess("analyzer.GoAnalyzer.parseFileCached")
+		return result, nil
+	}
+
+	src, err := os.ReadFile(task.path)
+	if err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.parseFileCached", err)
+		return nil, fmt.Errorf("failed to read file %s: %w", task.path, err)
+	}
+
+	key := a.cacheKey(task.path, src, task.platforms)
+	if result, ok := a.cache.load(key); ok {
+		tracer.ExitSuccess("analyzer.GoAnalyzer.parseFileCached")
+		return result, nil
+	}
+
+	result, err := a.parseFile(task.path, src, task.platforms)
+	if err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.parseFileCached", err)
+		return nil, err
+	}
+
+	if err := a.cache.store(key, result); err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.parseFileCached", err)
+		return nil, err
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.parseFileCached")
+	return result, nil
+}
+
+// PruneCache removes cache entries from dir that have not been used for
+// longer than maxAge. A zero maxAge removes every entry. It returns the
+// number of removed entries.
+func PruneCache(dir string, maxAge time.Duration) (int, error) {
+	tracer.Enter("analyzer.PruneCache")
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		if !strings.HasSuffix(path, cacheEntrySuffix) && !strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if maxAge > 0 && info.ModTime().After(cutoff) {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed++
+
+		return nil
+	})
+	if err != nil {
+		tracer.ExitError("analyzer.PruneCache", err)
+		return removed, fmt.Errorf("failed to prune cache: %w", err)
+	}
+
+	tracer.ExitSuccess("analyzer.PruneCache")
+	return removed, nil
+}
@@ -0,0 +1,251 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/callgraph/static"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// Call graph algorithms for Options.CallGraph. CallGraphSyntax is the
+// default: calls are collected from the syntax of each function. The
+// others build the SSA form of the loaded packages and run the algorithm
+// of the same name from golang.org/x/tools/go/callgraph.
+const (
+	CallGraphSyntax = "syntax"
+	CallGraphStatic = "static"
+	CallGraphCHA    = "cha"
+	CallGraphRTA    = "rta"
+	CallGraphVTA    = "vta"
+)
+
+// CallGraphAlgorithms lists the accepted values of Options.CallGraph.
+var CallGraphAlgorithms = []string{
+	CallGraphSyntax, CallGraphStatic, CallGraphCHA, CallGraphRTA, CallGraphVTA,
+}
+
+var errUnknownCallGraph = errors.New("unknown call graph algorithm")
+
+// callGraphAlgorithm returns the configured algorithm, or an error when it
+// is not one of CallGraphAlgorithms.
+func (a *GoAnalyzer) callGraphAlgorithm() (string, error) {
+	if a.opts.CallGraph == "" {
+		return CallGraphSyntax, nil
+	}
+
+	for _, algorithm := range CallGraphAlgorithms {
+		if a.opts.CallGraph == algorithm {
+			return algorithm, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", errUnknownCallGraph, a.opts.CallGraph)
+}
+
+// usesSSA reports whether calls come from an SSA call graph instead of
+// the syntax.
+func (a *GoAnalyzer) usesSSA() bool {
+	algorithm, err := a.callGraphAlgorithm()
+	return err == nil && algorithm != CallGraphSyntax
+}
+
+// addCallGraph builds the SSA form of pkgs, runs the configured call graph
+// algorithm and records its edges as calls of the calling components.
+// Calls from function literals are attributed to the enclosing
+// declaration; calls of function literals and synthetic wrappers are
+// dropped or attributed to the wrapped method.
+func (a *GoAnalyzer) addCallGraph(pkgs []*packages.Package) {
+	tracer.Enter("analyzer.GoAnalyzer.addCallGraph")
+
+	prog, ssaPkgs := ssautil.Packages(pkgs, ssa.InstantiateGenerics)
+	prog.Build()
+
+	var graph *callgraph.Graph
+
+	switch a.opts.CallGraph {
+	case CallGraphStatic:
+		graph = static.CallGraph(prog)
+	case CallGraphCHA:
+		graph = cha.CallGraph(prog)
+	case CallGraphRTA:
+		// Without roots, e.g. in a module without packages, there is
+		// nothing reachable and no call graph to record.
+		roots := rtaRoots(ssaPkgs)
+		if len(roots) == 0 {
+			tracer.ExitSuccess("analyzer.GoAnalyzer.addCallGraph")
+			return
+		}
+		graph = rta.Analyze(roots, true).CallGraph
+	case CallGraphVTA:
+		graph = vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog))
+	}
+
+	seen := make(map[string]bool)
+	for from, calls := range a.ssaCalls {
+		for _, call := range calls {
+			seen[from+"\x00"+callKey(call)] = true
+		}
+	}
+
+	_ = callgraph.GraphVisitEdges(graph, func(edge *callgraph.Edge) error {
+		caller, callee := edge.Caller.Func, edge.Callee.Func
+		if caller == nil || caller.Synthetic != "" || callee.Parent() != nil {
+			return nil
+		}
+
+		from, to := ssaFunctionID(caller), ssaFunctionID(callee)
+		if from == "" || to == "" {
+			return nil
+		}
+
+		call := CallInfo{
+			Target:   callee.Name(),
+			Line:     prog.Fset.Position(edge.Pos()).Line,
+			Resolved: to,
+		}
+		switch edge.Site.(type) {
+		case *ssa.Go:
+			call.Kind = callKindGo
+		case *ssa.Defer:
+			call.Kind = callKindDefer
+		}
+
+		key := from + "\x00" + callKey(call)
+		if !seen[key] {
+			seen[key] = true
+			a.ssaCalls[from] = append(a.ssaCalls[from], call)
+		}
+
+		return nil
+	})
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.addCallGraph")
+}
+
+// buildSSACallEdges adds the call edges of the SSA call graph between
+// collected functions and methods, tagged with the algorithm.
+func (a *GoAnalyzer) buildSSACallEdges() {
+	tracer.Enter("analyzer.GoAnalyzer.buildSSACallEdges")
+
+	callers := make([]string, 0, len(a.ssaCalls))
+	for id := range a.ssaCalls {
+		callers = append(callers, id)
+	}
+	sort.Strings(callers)
+
+	for _, id := range callers {
+		_, isFunc := a.functions[id]
+		_, isMethod := a.methods[id]
+		if !isFunc && !isMethod {
+			continue
+		}
+
+		for _, call := range a.ssaCalls[id] {
+			target := a.resolveCallTarget(call, "")
+			if target == "" {
+				continue
+			}
+
+			a.edges = append(a.edges, model.Edge{
+				From:      id,
+				To:        target,
+				Type:      callEdgeType(call.Kind),
+				Method:    call.Target,
+				Algorithm: a.opts.CallGraph,
+			})
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildSSACallEdges")
+}
+
+// rtaRoots returns the entry points for rapid type analysis: main and
+// init of the main packages. Without main packages, as in libraries,
+// every function of the loaded packages is a root. The generated main
+// packages of tests only add to the roots: on their own they would hide
+// every function the tests do not reach.
+func rtaRoots(pkgs []*ssa.Package) []*ssa.Function {
+	var roots, testMains []*ssa.Function
+
+	for _, pkg := range pkgs {
+		if pkg == nil || pkg.Pkg.Name() != "main" {
+			continue
+		}
+		for _, name := range []string{"main", "init"} {
+			fn := pkg.Func(name)
+			switch {
+			case fn == nil:
+			case strings.HasSuffix(pkg.Pkg.Path(), ".test"):
+				testMains = append(testMains, fn)
+			default:
+				roots = append(roots, fn)
+			}
+		}
+	}
+
+	if len(roots) > 0 {
+		return append(roots, testMains...)
+	}
+
+	for _, pkg := range pkgs {
+		if pkg == nil {
+			continue
+		}
+		for _, member := range pkg.Members {
+			switch m := member.(type) {
+			case *ssa.Function:
+				roots = append(roots, m)
+			case *ssa.Type:
+				roots = append(roots, declaredMethods(pkg, m.Type())...)
+			}
+		}
+	}
+
+	return roots
+}
+
+// declaredMethods returns the methods declared in pkg on t or *t.
+func declaredMethods(pkg *ssa.Package, t types.Type) []*ssa.Function {
+	var methods []*ssa.Function
+
+	mset := pkg.Prog.MethodSets.MethodSet(types.NewPointer(t))
+	for i := 0; i < mset.Len(); i++ {
+		if fn := pkg.Prog.MethodValue(mset.At(i)); fn != nil && fn.Pkg == pkg {
+			methods = append(methods, fn)
+		}
+	}
+
+	return methods
+}
+
+// ssaFunctionID maps an SSA function to the component ID of its
+// declaration, following function literals to the enclosing function and
+// generic instances to their origin.
+func ssaFunctionID(fn *ssa.Function) string {
+	for fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	if origin := fn.Origin(); origin != nil {
+		fn = origin
+	}
+
+	obj, ok := fn.Object().(*types.Func)
+	if !ok {
+		return ""
+	}
+
+	return funcObjectID(obj)
+}
@@ -0,0 +1,447 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// Call kinds of calls started by go and defer statements.
+const (
+	callKindGo    = "go"
+	callKindDefer = "defer"
+)
+
+// ChannelInfo holds a channel-typed struct field.
+type ChannelInfo struct {
+	Name string
+	Type string // channel type as written, e.g. "chan<- Job"
+	Line int
+}
+
+// ChanOp is a send or receive in a function body on a channel held by a
+// package-level variable or by a struct field. Field operations are
+// resolved like method calls: Owner is the static type holding Field.
+type ChanOp struct {
+	Receive bool
+	Range   bool // receive by a for range loop
+	Var     VarRef
+	Owner   *TypeRef
+	Field   string
+}
+
+// callKinds maps the calls started by go and defer statements in body to
+// their kind. Calls in the body of a function literal started that way
+// run on the new goroutine or when the function returns, so they take the
+// kind of the statement; the innermost statement wins.
+func callKinds(body *ast.BlockStmt) map[*ast.CallExpr]string {
+	kinds := make(map[*ast.CallExpr]string)
+
+	mark := func(call *ast.CallExpr, kind string) {
+		kinds[call] = kind
+
+		lit, ok := call.Fun.(*ast.FuncLit)
+		if !ok {
+			return
+		}
+		ast.Inspect(lit.Body, func(n ast.Node) bool {
+			if inner, ok := n.(*ast.CallExpr); ok {
+				kinds[inner] = kind
+			}
+			return true
+		})
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.GoStmt:
+			mark(stmt.Call, callKindGo)
+		case *ast.DeferStmt:
+			mark(stmt.Call, callKindDefer)
+		}
+		return true
+	})
+
+	return kinds
+}
+
+// callEdgeType returns the edge type for a call of the given kind.
+func callEdgeType(kind string) string {
+	switch kind {
+	case callKindGo:
+		return "spawns"
+	case callKindDefer:
+		return "defers"
+	}
+
+	return "calls"
+}
+
+// chanType returns the channel type of expr as written, or "" when expr
+// is not a channel type. Type-checked analysis also recognizes named
+// channel types.
+func (a *GoAnalyzer) chanType(expr ast.Expr, fc *fileContext) string {
+	if fc.info != nil {
+		t := fc.info.TypeOf(expr)
+		if t == nil {
+			return ""
+		}
+		if _, ok := t.Underlying().(*types.Chan); ok {
+			return types.ExprString(expr)
+		}
+		return ""
+	}
+
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+
+	if _, ok := expr.(*ast.ChanType); ok {
+		return types.ExprString(expr)
+	}
+
+	return ""
+}
+
+// valueChanType returns the channel type of the variable declared by the
+// index-th name of spec, or "".
+func (a *GoAnalyzer) valueChanType(spec *ast.ValueSpec, index int, fc *fileContext) string {
+	if spec.Type != nil {
+		return a.chanType(spec.Type, fc)
+	}
+
+	if fc.info != nil {
+		obj := fc.info.Defs[spec.Names[index]]
+		if obj == nil {
+			return ""
+		}
+		if _, ok := obj.Type().Underlying().(*types.Chan); !ok {
+			return ""
+		}
+		return types.TypeString(obj.Type(), func(pkg *types.Package) string {
+			if pkg.Path() == fc.pkgPath {
+				return ""
+			}
+			return pkg.Name()
+		})
+	}
+
+	// var jobs = make(chan Job, n)
+	if index >= len(spec.Values) {
+		return ""
+	}
+	call, ok := spec.Values[index].(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return ""
+	}
+	if ident, ok := call.Fun.(*ast.Ident); !ok || ident.Name != "make" {
+		return ""
+	}
+
+	return a.chanType(call.Args[0], fc)
+}
+
+// collectChanOps returns the channel sends and receives of a function
+// declaration on channels that are not local to it.
+func (a *GoAnalyzer) collectChanOps(decl *ast.FuncDecl, fc *fileContext, scope *localScope) []ChanOp {
+	tracer.Enter("analyzer.GoAnalyzer.collectChanOps")
+
+	if decl.Body == nil {
+		tracer.ExitSuccess("analyzer.GoAnalyzer.collectChanOps")
+		return nil
+	}
+
+	locals := localNames(decl)
+	var ops []ChanOp
+
+	add := func(expr ast.Expr, receive, isRange bool) {
+		op, ok := a.chanOp(expr, locals, fc, scope)
+		if !ok {
+			return
+		}
+		op.Receive, op.Range = receive, isRange
+		ops = append(ops, op)
+	}
+
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.SendStmt:
+			add(stmt.Chan, false, false)
+		case *ast.UnaryExpr:
+			if stmt.Op == token.ARROW {
+				add(stmt.X, true, false)
+			}
+		case *ast.RangeStmt:
+			add(stmt.X, true, true)
+		}
+		return true
+	})
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.collectChanOps")
+	return ops
+}
+
+// chanOp describes the channel expr refers to: a package-level variable
+// or a field selected from a value of known type.
+func (a *GoAnalyzer) chanOp(expr ast.Expr, locals map[string]bool, fc *fileContext, scope *localScope) (ChanOp, bool) {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if locals[e.Name] {
+			return ChanOp{}, false
+		}
+		ref, ok := a.varRef(e, fc.pkgPath, fc)
+		return ChanOp{Var: ref}, ok
+	case *ast.SelectorExpr:
+		if ident, ok := e.X.(*ast.Ident); ok && !locals[ident.Name] {
+			if impPath, ok := fc.imports.resolve(ident.Name); ok {
+				ref, ok := a.varRef(e.Sel, impPath, fc)
+				return ChanOp{Var: ref}, ok
+			}
+		}
+
+		owner := scope.typeOf(e.X)
+		if fc.info != nil {
+			owner = nil
+			if sel, ok := fc.info.Selections[e]; ok && sel.Kind() == types.FieldVal {
+				if name, pkg := namedTypeOf(sel.Recv()); name != "" {
+					owner = &TypeRef{Name: name, Pkg: pkg}
+				}
+			}
+		}
+		if owner == nil {
+			return ChanOp{}, false
+		}
+		return ChanOp{Owner: owner, Field: e.Sel.Name}, true
+	}
+
+	return ChanOp{}, false
+}
+
+func mergeChanOps(existing, added []ChanOp) []ChanOp {
+	seen := make(map[string]bool, len(existing))
+	for _, op := range existing {
+		seen[chanOpKey(op)] = true
+	}
+
+	for _, op := range added {
+		key := chanOpKey(op)
+		if !seen[key] {
+			seen[key] = true
+			existing = append(existing, op)
+		}
+	}
+
+	return existing
+}
+
+func chanOpKey(op ChanOp) string {
+	key := op.Var.Pkg + "." + op.Var.Name
+	if op.Owner != nil {
+		key = op.Owner.Pkg + "." + op.Owner.Name + "." + op.Owner.Call
+		for _, field := range op.Owner.Fields {
+			key += "." + field
+		}
+		key += "." + op.Field
+	}
+
+	if op.Receive {
+		key += ":receive"
+	}
+	if op.Range {
+		key += ":range"
+	}
+
+	return key
+}
+
+// chanTarget returns the ID of the channel field or variable op refers
+// to, or "". Range loops only count as receives on known channels, since
+// they also iterate over slices and maps.
+func (a *GoAnalyzer) chanTarget(op ChanOp) string {
+	if op.Owner != nil {
+		typeID := a.resolveTypeRef(*op.Owner)
+		if typeID == "" {
+			return ""
+		}
+		return a.findChannel(typeID, op.Field, map[string]bool{})
+	}
+
+	id := a.varTarget(op.Var)
+	if id == "" || a.vars[id].Kind != "var" {
+		return ""
+	}
+	if op.Range && a.vars[id].Chan == "" {
+		return ""
+	}
+
+	return id
+}
+
+// findChannel returns the ID of channel field name of typeID, following
+// embedded types for promoted fields.
+func (a *GoAnalyzer) findChannel(typeID, name string, visited map[string]bool) string {
+	typeInfo, exists := a.types[typeID]
+	if !exists || visited[typeID] {
+		return ""
+	}
+	visited[typeID] = true
+
+	for _, channel := range typeInfo.Channels {
+		if channel.Name == name {
+			return typeID + "." + name
+		}
+	}
+
+	for _, embed := range typeInfo.Embeds {
+		if id := a.findChannel(embed.TypePkg+"."+embed.TypeName, name, visited); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// buildChannelNodes adds a component per channel-typed struct field,
+// contained in its struct.
+func (a *GoAnalyzer) buildChannelNodes() {
+	tracer.Enter("analyzer.GoAnalyzer.buildChannelNodes")
+
+	for typeID, typeInfo := range a.types {
+		for _, channel := range typeInfo.Channels {
+			id := typeID + "." + channel.Name
+
+			a.nodes = append(a.nodes, model.Node{
+				ID:         id,
+				Title:      channel.Name,
+				Entity:     "field",
+				Underlying: channel.Type,
+				File:       a.sourceFile(typeInfo.File),
+				Line:       channel.Line,
+				Exported:   exportedFlag(channel.Name),
+				Platforms:  typeInfo.Platforms,
+			})
+
+			a.edges = append(a.edges, model.Edge{
+				From: typeID,
+				To:   id,
+				Type: "contains",
+			})
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildChannelNodes")
+}
+
+// buildChannelEdges links functions and methods to the channels they send
+// to or receive from.
+func (a *GoAnalyzer) buildChannelEdges() {
+	tracer.Enter("analyzer.GoAnalyzer.buildChannelEdges")
+
+	lists := make(map[string][]ChanOp, len(a.functions)+len(a.methods))
+	for id, funcInfo := range a.functions {
+		lists[id] = funcInfo.ChanOps
+	}
+	for id, methodInfo := range a.methods {
+		lists[id] = methodInfo.ChanOps
+	}
+
+	ids := make([]string, 0, len(lists))
+	for id := range lists {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		seen := make(map[string]bool)
+
+		for _, op := range lists[id] {
+			target := a.chanTarget(op)
+			if target == "" {
+				continue
+			}
+
+			edgeType := "sends"
+			if op.Receive {
+				edgeType = "receives"
+			}
+
+			if seen[edgeType+target] {
+				continue
+			}
+			seen[edgeType+target] = true
+
+			a.edges = append(a.edges, model.Edge{
+				From: id,
+				To:   target,
+				Type: edgeType,
+			})
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildChannelEdges")
+}
+
+// concurrencyEdges lists the edge types of the concurrency view.
+var concurrencyEdges = map[string]bool{
+	"spawns":   true,
+	"defers":   true,
+	"sends":    true,
+	"receives": true,
+}
+
+// ConcurrencyView reduces graph to its concurrent structure: goroutines
+// started with go, deferred calls, channel fields and variables, and the
+// functions sending to or receiving from them. Channels are kept with the
+// components containing them.
+func ConcurrencyView(graph *model.Graph) *model.Graph {
+	tracer.Enter("analyzer.ConcurrencyView")
+
+	keep := make(map[string]bool)
+	channels := make(map[string]bool)
+
+	for _, node := range graph.Nodes {
+		if isChannelNode(node) {
+			keep[node.ID] = true
+			channels[node.ID] = true
+		}
+	}
+
+	view := &model.Graph{}
+
+	for _, edge := range graph.Edges {
+		if concurrencyEdges[edge.Type] || (edge.Type == "contains" && channels[edge.To]) {
+			keep[edge.From] = true
+			keep[edge.To] = true
+			view.Edges = append(view.Edges, edge)
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		if keep[node.ID] {
+			view.Nodes = append(view.Nodes, node)
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.ConcurrencyView")
+	return view
+}
+
+func isChannelNode(node model.Node) bool {
+	return (node.Entity == "field" || node.Entity == "var") && node.Underlying != ""
+}
@@ -0,0 +1,217 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// UnreachableTag marks components that FindDeadCode reports.
+const UnreachableTag = "unreachable"
+
+// DeadCodeOptions selects the roots of FindDeadCode. The main function of
+// main packages, every init function and every package-level variable or
+// constant, whose initializer runs at program start, are always roots.
+type DeadCodeOptions struct {
+	// Exported makes the exported declarations of importable packages,
+	// those that are neither main nor internal, roots.
+	Exported bool
+	// Tests makes test, benchmark, fuzz and example functions roots.
+	Tests bool
+	// Roots lists additional root components as patterns for
+	// tracer.MatchComponentPattern, e.g. handlers registered through
+	// reflection.
+	Roots []string
+}
+
+// reachEdges lists the edge types along which reachability propagates.
+var reachEdges = map[string]bool{
+	"calls": true, "spawns": true, "defers": true, "references": true,
+	"uses": true, "embeds": true, "accepts": true, "returns": true,
+	"instantiates": true, "constrained-by": true, "reads": true,
+	"writes": true, "sends": true, "receives": true, "tests": true,
+	"mentions": true,
+}
+
+// typeEntities lists the entities of type declarations.
+var typeEntities = map[string]bool{
+	"struct": true, "interface": true, "alias": true, "functype": true,
+	"map": true, "slice": true, "array": true, "chan": true,
+	"pointer": true, "basic": true, "named": true,
+}
+
+// FindDeadCode returns the functions, methods and types of graph that are
+// not reachable from the roots, sorted by ID. Reachability follows calls,
+// references and type dependencies. A reachable method makes its type
+// reachable. A reachable type keeps its exported methods and the methods
+// implementing its interfaces, which may be called through an interface
+// value. Interface methods are part of their interface and never reported
+// on their own.
+func FindDeadCode(graph *model.Graph, opts DeadCodeOptions) []model.Node {
+	tracer.Enter("analyzer.FindDeadCode")
+
+	nodes := make(map[string]model.Node, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+
+	out := make(map[string][]string)
+	owner := make(map[string]string)        // method -> type
+	methods := make(map[string][]string)    // type -> methods
+	implements := make(map[string][]string) // type -> interfaces
+	pkgOf := make(map[string]string)        // declaration -> package
+	mainPkgs := make(map[string]bool)
+
+	for _, node := range graph.Nodes {
+		if node.Entity == "package" && node.Title == "main" {
+			mainPkgs[node.ID] = true
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		switch {
+		case reachEdges[edge.Type]:
+			out[edge.From] = append(out[edge.From], edge.To)
+		case edge.Type == "contains" && typeEntities[nodes[edge.From].Entity] && nodes[edge.To].Entity == "method":
+			owner[edge.To] = edge.From
+			methods[edge.From] = append(methods[edge.From], edge.To)
+		case edge.Type == "implements":
+			implements[edge.From] = append(implements[edge.From], edge.To)
+		case edge.Type == "contains" && nodes[edge.From].Entity == "package":
+			pkgOf[edge.To] = edge.From
+		}
+	}
+
+	for methodID, typeID := range owner {
+		pkgOf[methodID] = pkgOf[typeID]
+	}
+
+	reachable := make(map[string]bool)
+	var queue []string
+
+	mark := func(id string) {
+		if !reachable[id] {
+			reachable[id] = true
+			queue = append(queue, id)
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		if isDeadCodeRoot(node, pkgOf[node.ID], mainPkgs, opts) {
+			mark(node.ID)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		for _, target := range out[id] {
+			mark(target)
+		}
+
+		if typeID, ok := owner[id]; ok {
+			mark(typeID)
+		}
+
+		if nodes[id].Entity == "interface" || !typeEntities[nodes[id].Entity] {
+			continue
+		}
+
+		ifaceMethods := make(map[string]bool)
+		for _, ifaceID := range implements[id] {
+			for _, methodID := range methods[ifaceID] {
+				ifaceMethods[nodes[methodID].Title] = true
+			}
+		}
+
+		for _, methodID := range methods[id] {
+			method := nodes[methodID]
+			if isExported(method) || ifaceMethods[method.Title] {
+				mark(methodID)
+			}
+		}
+	}
+
+	var dead []model.Node
+	for _, node := range graph.Nodes {
+		if reachable[node.ID] {
+			continue
+		}
+
+		switch {
+		case node.Entity == "function", typeEntities[node.Entity]:
+		case node.Entity == "method" && nodes[owner[node.ID]].Entity != "interface":
+		default:
+			continue
+		}
+
+		dead = append(dead, node)
+	}
+
+	sort.Slice(dead, func(i, j int) bool {
+		return dead[i].ID < dead[j].ID
+	})
+
+	tracer.ExitSuccess("analyzer.FindDeadCode")
+	return dead
+}
+
+// isDeadCodeRoot reports whether node is a root of the reachability
+// analysis.
+func isDeadCodeRoot(node model.Node, pkg string, mainPkgs map[string]bool, opts DeadCodeOptions) bool {
+	for _, pattern := range opts.Roots {
+		if tracer.MatchComponentPattern(node.ID, pattern) {
+			return true
+		}
+	}
+
+	switch node.Entity {
+	case "var", "const":
+		return true
+	case "test":
+		return opts.Tests
+	case "function":
+		if node.Title == "init" || (node.Title == "main" && mainPkgs[pkg]) {
+			return true
+		}
+	}
+
+	if !opts.Exported || !isExported(node) || pkg == "" {
+		return false
+	}
+
+	return !mainPkgs[pkg] && !isInternalPackage(pkg)
+}
+
+func isExported(node model.Node) bool {
+	return node.Exported != nil && *node.Exported
+}
+
+// isInternalPackage reports whether the go command forbids importing
+// pkgPath from other modules.
+func isInternalPackage(pkgPath string) bool {
+	return strings.HasPrefix(pkgPath, "internal/") || strings.Contains(pkgPath, "/internal/") ||
+		strings.HasSuffix(pkgPath, "/internal") || pkgPath == "internal"
+}
+
+// MarkUnreachable adds UnreachableTag to the components of graph listed
+// in dead.
+func MarkUnreachable(graph *model.Graph, dead []model.Node) {
+	tracer.Enter("analyzer.MarkUnreachable")
+
+	ids := make(map[string]bool, len(dead))
+	for _, node := range dead {
+		ids[node.ID] = true
+	}
+
+	for i := range graph.Nodes {
+		if ids[graph.Nodes[i].ID] {
+			graph.Nodes[i].Tags = append(graph.Nodes[i].Tags, UnreachableTag)
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.MarkUnreachable")
+}
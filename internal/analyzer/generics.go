@@ -0,0 +1,353 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// TypeParamInfo holds a type parameter of a generic type or function.
+type TypeParamInfo struct {
+	Name       string
+	Constraint string // constraint as written, e.g. "comparable" or "~int | ~string"
+	TypeName   string // named constraint type, empty for inline constraints
+	TypePkg    string
+}
+
+// InstanceInfo holds an instantiation of a generic type or function.
+type InstanceInfo struct {
+	TypeName string // name of the generic type or function
+	TypePkg  string
+	Args     []string // type arguments as written
+}
+
+// unwrapIndex strips explicit instantiations such as F[int] or T[K, V]
+// from expr.
+func unwrapIndex(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		return e.X
+	case *ast.IndexListExpr:
+		return e.X
+	}
+
+	return expr
+}
+
+// typeParamNames returns the names declared by a type parameter list.
+func typeParamNames(list *ast.FieldList) map[string]bool {
+	if list == nil {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	for _, field := range list.List {
+		for _, name := range field.Names {
+			names[name.Name] = true
+		}
+	}
+
+	return names
+}
+
+// receiverTypeParams returns the type parameter names of a generic
+// receiver such as *Cache[K, V].
+func receiverTypeParams(expr ast.Expr) map[string]bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	var indices []ast.Expr
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		indices = []ast.Expr{e.Index}
+	case *ast.IndexListExpr:
+		indices = e.Indices
+	}
+
+	if len(indices) == 0 {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	for _, index := range indices {
+		if ident, ok := index.(*ast.Ident); ok {
+			names[ident.Name] = true
+		}
+	}
+
+	return names
+}
+
+// collectTypeParams extracts the type parameters of a generic declaration
+// together with their constraints.
+func (a *GoAnalyzer) collectTypeParams(list *ast.FieldList, fc *fileContext) []TypeParamInfo {
+	tracer.Enter("analyzer.GoAnalyzer.collectTypeParams")
+
+	if list == nil {
+		tracer.ExitSuccess("analyzer.GoAnalyzer.collectTypeParams")
+		return nil
+	}
+
+	var params []TypeParamInfo
+
+	for _, field := range list.List {
+		param := TypeParamInfo{Constraint: types.ExprString(field.Type)}
+
+		switch unwrapIndex(field.Type).(type) {
+		case *ast.Ident, *ast.SelectorExpr:
+			param.TypeName, param.TypePkg = a.resolveTypeName(field.Type, fc)
+		}
+
+		for _, name := range field.Names {
+			param.Name = name.Name
+			params = append(params, param)
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.collectTypeParams")
+	return params
+}
+
+// collectInstances returns the instantiations of generic types and
+// functions within node. Type-checked analysis also reports instantiations
+// with inferred type arguments; syntactic analysis only sees explicit ones,
+// which are filtered against the collected generics in buildGraph.
+func (a *GoAnalyzer) collectInstances(node ast.Node, fc *fileContext) []InstanceInfo {
+	tracer.Enter("analyzer.GoAnalyzer.collectInstances")
+
+	var instances []InstanceInfo
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		if fc.info != nil {
+			if ident, ok := n.(*ast.Ident); ok {
+				if inst, ok := a.typedInstance(ident, fc); ok {
+					instances = append(instances, inst)
+				}
+			}
+			return true
+		}
+
+		var x ast.Expr
+		var indices []ast.Expr
+
+		switch e := n.(type) {
+		case *ast.IndexExpr:
+			x, indices = e.X, []ast.Expr{e.Index}
+		case *ast.IndexListExpr:
+			x, indices = e.X, e.Indices
+		default:
+			return true
+		}
+
+		inst := InstanceInfo{}
+		switch target := x.(type) {
+		case *ast.Ident:
+			if fc.typeParams[target.Name] {
+				return true
+			}
+			inst.TypeName, inst.TypePkg = target.Name, fc.pkgPath
+		case *ast.SelectorExpr:
+			ident, ok := target.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			impPath, ok := fc.imports.resolve(ident.Name)
+			if !ok {
+				return true
+			}
+			inst.TypeName, inst.TypePkg = target.Sel.Name, impPath
+		default:
+			return true
+		}
+
+		for _, index := range indices {
+			inst.Args = append(inst.Args, types.ExprString(index))
+		}
+		instances = append(instances, inst)
+
+		return true
+	})
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.collectInstances")
+	return instances
+}
+
+func (a *GoAnalyzer) typedInstance(ident *ast.Ident, fc *fileContext) (InstanceInfo, bool) {
+	instance, ok := fc.info.Instances[ident]
+	if !ok {
+		return InstanceInfo{}, false
+	}
+
+	inst := InstanceInfo{}
+	switch obj := fc.info.Uses[ident].(type) {
+	case *types.TypeName:
+		inst.TypeName, inst.TypePkg = typeNameObject(obj)
+	case *types.Func:
+		if obj.Pkg() == nil {
+			return InstanceInfo{}, false
+		}
+		inst.TypeName, inst.TypePkg = obj.Name(), obj.Pkg().Path()
+	default:
+		return InstanceInfo{}, false
+	}
+
+	qualifier := func(pkg *types.Package) string {
+		if pkg.Path() == fc.pkgPath {
+			return ""
+		}
+		return pkg.Name()
+	}
+
+	for i := 0; i < instance.TypeArgs.Len(); i++ {
+		inst.Args = append(inst.Args, types.TypeString(instance.TypeArgs.At(i), qualifier))
+	}
+
+	return inst, true
+}
+
+func mergeInstances(existing, added []InstanceInfo) []InstanceInfo {
+	seen := make(map[string]bool, len(existing))
+	for _, inst := range existing {
+		seen[instanceKey(inst)] = true
+	}
+
+	for _, inst := range added {
+		key := instanceKey(inst)
+		if !seen[key] {
+			seen[key] = true
+			existing = append(existing, inst)
+		}
+	}
+
+	return existing
+}
+
+func instanceKey(inst InstanceInfo) string {
+	return inst.TypePkg + "." + inst.TypeName + "[" + strings.Join(inst.Args, ", ") + "]"
+}
+
+// typeParamStrings renders type parameters for the type_params attribute
+// of a component, e.g. "K comparable".
+func typeParamStrings(params []TypeParamInfo) []string {
+	if len(params) == 0 {
+		return nil
+	}
+
+	result := make([]string, 0, len(params))
+	for _, param := range params {
+		result = append(result, param.Name+" "+param.Constraint)
+	}
+
+	return result
+}
+
+// typeParamLists returns the type parameter lists of all generic types and
+// functions.
+func (a *GoAnalyzer) typeParamLists() [][]TypeParamInfo {
+	var lists [][]TypeParamInfo
+
+	for _, typeInfo := range a.types {
+		if len(typeInfo.TypeParams) > 0 {
+			lists = append(lists, typeInfo.TypeParams)
+		}
+	}
+
+	for _, funcInfo := range a.functions {
+		if len(funcInfo.TypeParams) > 0 {
+			lists = append(lists, funcInfo.TypeParams)
+		}
+	}
+
+	return lists
+}
+
+// genericTarget returns the ID of the generic type or function an
+// instantiation refers to, or "" when it is not a collected generic.
+func (a *GoAnalyzer) genericTarget(inst InstanceInfo) string {
+	if id := a.lookupTypeID(inst.TypePkg, inst.TypeName); id != "" {
+		if typeInfo, exists := a.types[id]; exists && len(typeInfo.TypeParams) > 0 {
+			return id
+		}
+	}
+
+	id := inst.TypePkg + "." + inst.TypeName
+	if funcInfo, exists := a.functions[id]; exists && len(funcInfo.TypeParams) > 0 {
+		return id
+	}
+
+	return ""
+}
+
+// buildGenericEdges links users of generic types and functions to them
+// with "instantiates" edges carrying the type arguments, and generic
+// declarations to the named interfaces constraining their parameters.
+func (a *GoAnalyzer) buildGenericEdges() {
+	tracer.Enter("analyzer.GoAnalyzer.buildGenericEdges")
+
+	type user struct {
+		id         string
+		owner      string // generic type whose own parameters are in scope
+		typeParams []TypeParamInfo
+		instances  []InstanceInfo
+	}
+
+	var users []user
+	for id, typeInfo := range a.types {
+		users = append(users, user{id, id, typeInfo.TypeParams, typeInfo.Instances})
+	}
+	for id, funcInfo := range a.functions {
+		users = append(users, user{id, id, funcInfo.TypeParams, funcInfo.Instances})
+	}
+	for id, methodInfo := range a.methods {
+		owner := methodInfo.Package + "." + methodInfo.Receiver
+		users = append(users, user{id, owner, nil, methodInfo.Instances})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].id < users[j].id
+	})
+
+	for _, u := range users {
+		seen := make(map[string]bool)
+
+		for _, inst := range u.instances {
+			target := a.genericTarget(inst)
+			key := target + instanceKey(inst)
+			if target == "" || target == u.owner || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			a.edges = append(a.edges, model.Edge{
+				From:     u.id,
+				To:       target,
+				Type:     "instantiates",
+				TypeArgs: inst.Args,
+			})
+		}
+
+		for _, param := range u.typeParams {
+			if param.TypeName == "" {
+				continue
+			}
+
+			target := a.typeDependencyTarget(FieldInfo{TypeName: param.TypeName, TypePkg: param.TypePkg})
+			if target == "" || seen[target] {
+				continue
+			}
+			seen[target] = true
+
+			a.edges = append(a.edges, model.Edge{
+				From: u.id,
+				To:   target,
+				Type: "constrained-by",
+			})
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildGenericEdges")
+}
@@ -0,0 +1,441 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// VarInfo holds information about a package-level variable or constant.
+type VarInfo struct {
+	Name      string
+	Package   string
+	Kind      string // var, const
+	Chan      string // channel type of channel variables
+	File      string
+	Line      int
+	EndLine   int
+	Doc       string     // first sentence of the doc comment
+	Refs      []CallInfo // functions and methods used in the initializer
+	Platforms []string
+}
+
+// VarRef is a reference from a function body to a package-level variable
+// or constant. Syntactic analysis records every identifier that is not
+// declared locally; references to other objects are dropped when the
+// graph is built.
+type VarRef struct {
+	Name  string
+	Pkg   string
+	Write bool // assigned, incremented or address taken
+}
+
+// parseValueDecl records the package-level variables or constants declared
+// by a var or const declaration.
+func (a *GoAnalyzer) parseValueDecl(decl *ast.GenDecl, fc *fileContext) {
+	tracer.Enter("analyzer.GoAnalyzer.parseValueDecl")
+
+	kind := "var"
+	if decl.Tok == token.CONST {
+		kind = "const"
+	}
+
+	for _, spec := range decl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+
+		var declDoc *ast.CommentGroup
+		if len(decl.Specs) == 1 {
+			declDoc = decl.Doc
+		}
+
+		for i, name := range valueSpec.Names {
+			if name.Name == "_" {
+				continue
+			}
+
+			chanType := ""
+			var refs []CallInfo
+			if kind == "var" {
+				chanType = a.valueChanType(valueSpec, i, fc)
+				if len(valueSpec.Values) == len(valueSpec.Names) {
+					refs = a.collectFuncRefs(valueSpec.Values[i], nil, fc, a.packageScope(fc))
+				}
+			}
+
+			addVar(fc.result.vars, fc.pkgPath+"."+name.Name, &VarInfo{
+				Name:      name.Name,
+				Package:   fc.pkgPath,
+				Kind:      kind,
+				Chan:      chanType,
+				File:      fc.filename,
+				Line:      fc.fset.Position(name.Pos()).Line,
+				EndLine:   fc.fset.Position(valueSpec.End()).Line,
+				Doc:       docSummary(valueSpec.Doc, declDoc, valueSpec.Comment),
+				Refs:      refs,
+				Platforms: fc.platforms,
+			})
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.parseValueDecl")
+}
+
+// addVar records a variable in vars, merging platform-specific variants.
+func addVar(vars map[string]*VarInfo, id string, varInfo *VarInfo) {
+	existing, exists := vars[id]
+	if !exists {
+		vars[id] = varInfo
+		return
+	}
+
+	existing.Platforms = mergePlatforms(existing.Platforms, varInfo.Platforms)
+	existing.Refs = mergeCalls(existing.Refs, varInfo.Refs)
+}
+
+func mergeVarRefs(existing, added []VarRef) []VarRef {
+	seen := make(map[VarRef]bool, len(existing))
+	for _, ref := range existing {
+		seen[ref] = true
+	}
+
+	for _, ref := range added {
+		if !seen[ref] {
+			seen[ref] = true
+			existing = append(existing, ref)
+		}
+	}
+
+	return existing
+}
+
+// collectVarRefs returns the references of a function declaration to
+// identifiers that may be package-level variables or constants.
+func (a *GoAnalyzer) collectVarRefs(decl *ast.FuncDecl, fc *fileContext) []VarRef {
+	tracer.Enter("analyzer.GoAnalyzer.collectVarRefs")
+
+	if decl.Body == nil {
+		tracer.ExitSuccess("analyzer.GoAnalyzer.collectVarRefs")
+		return nil
+	}
+
+	locals := localNames(decl)
+	writes := make(map[*ast.Ident]bool)
+
+	markWrite := func(expr ast.Expr) {
+		if ident := a.refBase(expr, locals, fc); ident != nil {
+			writes[ident] = true
+		}
+	}
+
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.AssignStmt:
+			if stmt.Tok != token.DEFINE {
+				for _, lhs := range stmt.Lhs {
+					markWrite(lhs)
+				}
+			}
+		case *ast.IncDecStmt:
+			markWrite(stmt.X)
+		case *ast.RangeStmt:
+			if stmt.Tok == token.ASSIGN {
+				markWrite(stmt.Key)
+				markWrite(stmt.Value)
+			}
+		case *ast.UnaryExpr:
+			if stmt.Op == token.AND {
+				markWrite(stmt.X)
+			}
+		}
+		return true
+	})
+
+	var refs []VarRef
+	seen := make(map[VarRef]bool)
+
+	add := func(ident *ast.Ident, pkg string) {
+		ref, ok := a.varRef(ident, pkg, fc)
+		if !ok {
+			return
+		}
+		ref.Write = writes[ident]
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.SelectorExpr:
+			if ident, ok := e.X.(*ast.Ident); ok && !locals[ident.Name] {
+				if impPath, ok := fc.imports.resolve(ident.Name); ok {
+					add(e.Sel, impPath)
+					return false
+				}
+			}
+			ast.Inspect(e.X, visit)
+			return false
+		case *ast.KeyValueExpr:
+			if _, ok := e.Key.(*ast.Ident); !ok {
+				ast.Inspect(e.Key, visit)
+			}
+			ast.Inspect(e.Value, visit)
+			return false
+		case *ast.BranchStmt, *ast.LabeledStmt:
+			if labeled, ok := e.(*ast.LabeledStmt); ok {
+				ast.Inspect(labeled.Stmt, visit)
+			}
+			return false
+		case *ast.Ident:
+			if !locals[e.Name] {
+				add(e, fc.pkgPath)
+			}
+		}
+		return true
+	}
+	ast.Inspect(decl.Body, visit)
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.collectVarRefs")
+	return refs
+}
+
+// refBase returns the identifier naming the variable an assigned
+// expression writes to: v in v = x, v[k] = x, v.f = x or pkg.V = x.
+func (a *GoAnalyzer) refBase(expr ast.Expr, locals map[string]bool, fc *fileContext) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			if ident, ok := e.X.(*ast.Ident); ok && !locals[ident.Name] {
+				if _, ok := fc.imports.resolve(ident.Name); ok {
+					return e.Sel
+				}
+			}
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// varRef turns an identifier into a reference. In typed mode only
+// package-level variables and constants are accepted.
+func (a *GoAnalyzer) varRef(ident *ast.Ident, pkg string, fc *fileContext) (VarRef, bool) {
+	if fc.info == nil {
+		if ident.Name == "_" || a.isBuiltin(ident.Name) {
+			return VarRef{}, false
+		}
+		return VarRef{Name: ident.Name, Pkg: pkg}, true
+	}
+
+	obj := fc.info.Uses[ident]
+	switch obj.(type) {
+	case *types.Var, *types.Const:
+	default:
+		return VarRef{}, false
+	}
+
+	if obj.Pkg() == nil || obj.Parent() != obj.Pkg().Scope() {
+		return VarRef{}, false
+	}
+
+	return VarRef{Name: obj.Name(), Pkg: obj.Pkg().Path()}, true
+}
+
+// localNames returns every name declared inside a function: receiver,
+// parameters, results and local declarations. Like localScope it is
+// flow-insensitive, so a local declaration hides a package-level name in
+// the whole function.
+func localNames(decl *ast.FuncDecl) map[string]bool {
+	names := make(map[string]bool)
+
+	addFields := func(list *ast.FieldList) {
+		if list == nil {
+			return
+		}
+		for _, field := range list.List {
+			for _, name := range field.Names {
+				names[name.Name] = true
+			}
+		}
+	}
+
+	addFields(decl.Recv)
+	addFields(decl.Type.TypeParams)
+	addFields(decl.Type.Params)
+	addFields(decl.Type.Results)
+
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.AssignStmt:
+			if stmt.Tok == token.DEFINE {
+				for _, lhs := range stmt.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						names[ident.Name] = true
+					}
+				}
+			}
+		case *ast.RangeStmt:
+			if stmt.Tok == token.DEFINE {
+				for _, expr := range []ast.Expr{stmt.Key, stmt.Value} {
+					if ident, ok := expr.(*ast.Ident); ok {
+						names[ident.Name] = true
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for _, name := range stmt.Names {
+				names[name.Name] = true
+			}
+		case *ast.TypeSpec:
+			names[stmt.Name.Name] = true
+		case *ast.FuncLit:
+			addFields(stmt.Type.Params)
+			addFields(stmt.Type.Results)
+		case *ast.TypeSwitchStmt:
+			if assign, ok := stmt.Assign.(*ast.AssignStmt); ok {
+				for _, lhs := range assign.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						names[ident.Name] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	return names
+}
+
+// varTarget returns the ID of the collected variable or constant ref
+// refers to, or "".
+func (a *GoAnalyzer) varTarget(ref VarRef) string {
+	id := ref.Pkg + "." + ref.Name
+	if _, exists := a.vars[id]; exists {
+		return id
+	}
+
+	return ""
+}
+
+// mutableVars returns the variables that some function writes to: global
+// mutable state.
+func (a *GoAnalyzer) mutableVars() map[string]bool {
+	mutable := make(map[string]bool)
+
+	for _, refs := range a.varRefLists() {
+		for _, ref := range refs {
+			if !ref.Write {
+				continue
+			}
+			if id := a.varTarget(ref); id != "" && a.vars[id].Kind == "var" {
+				mutable[id] = true
+			}
+		}
+	}
+
+	return mutable
+}
+
+func (a *GoAnalyzer) varRefLists() map[string][]VarRef {
+	lists := make(map[string][]VarRef, len(a.functions)+len(a.methods))
+
+	for id, funcInfo := range a.functions {
+		lists[id] = funcInfo.VarRefs
+	}
+
+	for id, methodInfo := range a.methods {
+		lists[id] = methodInfo.VarRefs
+	}
+
+	return lists
+}
+
+func (a *GoAnalyzer) buildVarNodes() {
+	tracer.Enter("analyzer.GoAnalyzer.buildVarNodes")
+
+	mutable := a.mutableVars()
+
+	for id, varInfo := range a.vars {
+		a.nodes = append(a.nodes, model.Node{
+			ID:         id,
+			Title:      varInfo.Name,
+			Entity:     varInfo.Kind,
+			Underlying: varInfo.Chan,
+			File:       a.sourceFile(varInfo.File),
+			Line:       varInfo.Line,
+			EndLine:    varInfo.EndLine,
+			Exported:   exportedFlag(varInfo.Name),
+			Doc:        varInfo.Doc,
+			Mutable:    mutable[id],
+			Platforms:  varInfo.Platforms,
+		})
+
+		a.edges = append(a.edges, model.Edge{
+			From: varInfo.Package,
+			To:   id,
+			Type: "contains",
+		})
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildVarNodes")
+}
+
+// buildVarEdges links functions and methods to the package-level variables
+// and constants they read or write.
+func (a *GoAnalyzer) buildVarEdges() {
+	tracer.Enter("analyzer.GoAnalyzer.buildVarEdges")
+
+	lists := a.varRefLists()
+
+	ids := make([]string, 0, len(lists))
+	for id := range lists {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		seen := make(map[string]bool)
+
+		for _, ref := range lists[id] {
+			target := a.varTarget(ref)
+			if target == "" {
+				continue
+			}
+
+			edgeType := "reads"
+			if ref.Write {
+				edgeType = "writes"
+			}
+
+			if seen[edgeType+target] {
+				continue
+			}
+			seen[edgeType+target] = true
+
+			a.edges = append(a.edges, model.Edge{
+				From: id,
+				To:   target,
+				Type: edgeType,
+			})
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildVarEdges")
+}
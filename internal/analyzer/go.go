@@ -6,8 +6,10 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/mshogin/archlint/internal/model"
@@ -16,25 +18,40 @@ import (
 
 // PackageInfo holds information about a Go package.
 type PackageInfo struct {
-	Name    string
-	Path    string
-	Dir     string
-	Imports []string
+	Name       string
+	Path       string
+	Dir        string
+	Module     string // path of the owning module, empty outside modules
+	Doc        string // summary of the package comment
+	Imports    []string
+	DotImports []string
+	Platforms  []string
+	// Uses counts the selectors per imported symbol, keyed by import path
+	// and symbol name, e.g. Uses["example.com/app/store"]["Open"].
+	Uses map[string]map[string]int
 }
 
 // TypeInfo holds information about a type declaration.
 type TypeInfo struct {
 	Name       string
 	Package    string
-	Kind       string // struct, interface
+	Kind       string // struct, interface, alias, functype, map, slice, array, chan, pointer, basic, named
+	Underlying string // underlying type as written, empty for struct and interface
 	File       string
 	Line       int
+	EndLine    int
+	Doc        string // first sentence of the doc comment
 	Fields     []FieldInfo
-	Embeds     []string
-	Implements []string
+	Embeds     []FieldInfo
+	Channels   []ChannelInfo
+	Implements []string // IDs of interfaces the type satisfies
+	TypeParams []TypeParamInfo
+	Instances  []InstanceInfo // generic instantiations in the declaration
+	Platforms  []string
 }
 
-// FieldInfo holds information about a struct field.
+// FieldInfo holds information about a struct field or an embedded type.
+// For embedded types Name equals TypeName.
 type FieldInfo struct {
 	Name     string
 	TypeName string
@@ -43,21 +60,49 @@ type FieldInfo struct {
 
 // FunctionInfo holds information about a function.
 type FunctionInfo struct {
-	Name    string
-	Package string
-	File    string
-	Line    int
-	Calls   []CallInfo
+	Name       string
+	Package    string
+	File       string
+	Line       int
+	EndLine    int
+	Doc        string // first sentence of the doc comment
+	Decl       string // declaration as written, without the body
+	Calls      []CallInfo
+	Refs       []CallInfo // functions and methods used as values
+	Params     []FieldInfo
+	Results    []FieldInfo
+	BodyTypes  []FieldInfo // types named in the body
+	TypeParams []TypeParamInfo
+	Instances  []InstanceInfo // generic instantiations in signature and body
+	VarRefs    []VarRef
+	ChanOps    []ChanOp
+	InTestFile bool
+	TestKind   string // test, benchmark, fuzz, example; empty for non-tests
+	Platforms  []string
 }
 
 // MethodInfo holds information about a method.
+// Interface methods are recorded with the interface as Receiver.
 type MethodInfo struct {
-	Name     string
-	Receiver string
-	Package  string
-	File     string
-	Line     int
-	Calls    []CallInfo
+	Name       string
+	Receiver   string
+	Package    string
+	File       string
+	Line       int
+	EndLine    int
+	Doc        string // first sentence of the doc comment
+	Signature  string // normalized, see signatureString
+	Decl       string // declaration as written, without the body
+	Calls      []CallInfo
+	Refs       []CallInfo // functions and methods used as values
+	Params     []FieldInfo
+	Results    []FieldInfo
+	BodyTypes  []FieldInfo    // types named in the body
+	Instances  []InstanceInfo // generic instantiations in signature and body
+	VarRefs    []VarRef
+	ChanOps    []ChanOp
+	InTestFile bool
+	Platforms  []string
 }
 
 // CallInfo holds information about a function/method call.
@@ -66,34 +111,128 @@ type CallInfo struct {
 	IsMethod bool
 	Receiver string
 	Line     int
+	Kind     string // go or defer for calls started by those statements
+	// ReceiverType is the inferred static type of the receiver expression
+	// of a method call, nil when it is unknown.
+	ReceiverType *TypeRef
+	// Resolved is the component ID of the declaring object, filled in
+	// by type-checked analysis.
+	Resolved string
+}
+
+// Options configures a GoAnalyzer.
+type Options struct {
+	// TypeCheck loads the module with go/packages and resolves identifiers
+	// through go/types instead of parsing each file in isolation.
+	TypeCheck bool
+	// ExternalPackages adds a component for every imported third-party
+	// package in addition to one component per required module.
+	ExternalPackages bool
+	// Jobs bounds the number of files parsed concurrently. Zero or a
+	// negative value uses one worker per CPU. While a trace is recorded
+	// files are parsed by a single worker.
+	Jobs int
+	// IncludeTests analyzes _test.go files, including external test
+	// packages, and adds test components with "tests" edges.
+	IncludeTests bool
+	// GOOS, GOARCH and Tags select the build context whose constraints
+	// decide which files are analyzed. Empty values use the defaults of
+	// the running toolchain.
+	GOOS   string
+	GOARCH string
+	Tags   []string
+	// Platforms switches to union mode: files are analyzed when they
+	// belong to any of the listed GOOS/GOARCH pairs, and components are
+	// tagged with the platforms they exist on.
+	Platforms []string
+	// CacheDir enables the per-file analysis cache stored in that
+	// directory. Unchanged files are read from the cache instead of being
+	// parsed again. The cache is not used in type-checked mode.
+	CacheDir string
+	// ImportDetail lists the symbols a package uses from each import and
+	// the number of uses on its import edges.
+	ImportDetail bool
+	// CallGraph selects the algorithm computing call edges, one of
+	// CallGraphAlgorithms. Every algorithm but the default
+	// CallGraphSyntax implies type-checked loading.
+	CallGraph string
+	// BodyTypes adds "mentions" edges from functions and methods to the
+	// types named in their bodies, e.g. in composite literals and
+	// conversions. FindDeadCode follows them to keep such types alive.
+	BodyTypes bool
+}
+
+// fileContext carries per-file state through the declaration parsers.
+type fileContext struct {
+	fset      *token.FileSet
+	pkgPath   string
+	filename  string
+	platforms []string // union mode platforms of the file
+	imports   *importTable
+	result    *fileResult // receives the extracted declarations
+	info      *types.Info // nil in syntactic mode
+	// typeParams holds the type parameters in scope of the declaration
+	// being parsed. They never resolve to package-level types.
+	typeParams map[string]bool
 }
 
 // GoAnalyzer analyzes Go source code and builds an architecture graph.
 type GoAnalyzer struct {
-	packages  map[string]*PackageInfo
-	types     map[string]*TypeInfo
-	functions map[string]*FunctionInfo
-	methods   map[string]*MethodInfo
-	nodes     []model.Node
-	edges     []model.Edge
-	baseDir   string
+	opts       Options
+	packages   map[string]*PackageInfo
+	types      map[string]*TypeInfo
+	functions  map[string]*FunctionInfo
+	methods    map[string]*MethodInfo
+	vars       map[string]*VarInfo
+	ssaCalls   map[string][]CallInfo      // calls by caller ID from the SSA call graph
+	implements map[string]map[string]bool // interface IDs by type ID from go/types
+	requires   map[string]*ModuleRequirement
+	externals  map[string]*ExternalInfo
+	contexts   []buildContext
+	files      []fileTask
+	cache      *fileCache
+	modules    []*ModuleInfo // deepest directory first
+	roots      []string      // directories walked in syntactic mode
+	nodes      []model.Node
+	edges      []model.Edge
+	baseDir    string
 	modulePath string
 }
 
-// NewGoAnalyzer creates a new GoAnalyzer instance.
+// NewGoAnalyzer creates a new GoAnalyzer instance with default options.
 func NewGoAnalyzer() *GoAnalyzer {
 	tracer.Enter("analyzer.NewGoAnalyzer")
 
+	a := NewGoAnalyzerWithOptions(Options{})
+
+	tracer.ExitSuccess("analyzer.NewGoAnalyzer")
+	return a
+}
+
+// NewGoAnalyzerWithOptions creates a new GoAnalyzer instance with the given options.
+func NewGoAnalyzerWithOptions(opts Options) *GoAnalyzer {
+	tracer.Enter("analyzer.NewGoAnalyzerWithOptions")
+
 	a := &GoAnalyzer{
-		packages:  make(map[string]*PackageInfo),
-		types:     make(map[string]*TypeInfo),
-		functions: make(map[string]*FunctionInfo),
-		methods:   make(map[string]*MethodInfo),
-		nodes:     []model.Node{},
-		edges:     []model.Edge{},
+		opts:       opts,
+		packages:   make(map[string]*PackageInfo),
+		types:      make(map[string]*TypeInfo),
+		functions:  make(map[string]*FunctionInfo),
+		methods:    make(map[string]*MethodInfo),
+		vars:       make(map[string]*VarInfo),
+		ssaCalls:   make(map[string][]CallInfo),
+		implements: make(map[string]map[string]bool),
+		requires:   make(map[string]*ModuleRequirement),
+		externals:  make(map[string]*ExternalInfo),
+		nodes:      []model.Node{},
+		edges:      []model.Edge{},
 	}
 
-	tracer.ExitSuccess("analyzer.NewGoAnalyzer")
+	if opts.CacheDir != "" {
+		a.cache = &fileCache{dir: opts.CacheDir}
+	}
+
+	tracer.ExitSuccess("analyzer.NewGoAnalyzerWithOptions")
 	return a
 }
 
@@ -108,12 +247,42 @@ func (a *GoAnalyzer) Analyze(dir string) (*model.Graph, error) {
 	}
 	a.baseDir = absDir
 
-	a.modulePath = a.detectModulePath()
-
-	err = filepath.Walk(absDir, a.walkFunc)
+	a.contexts, err = newBuildContexts(a.opts)
 	if err != nil {
 		tracer.ExitError("analyzer.GoAnalyzer.Analyze", err)
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+		return nil, err
+	}
+
+	if _, err := a.callGraphAlgorithm(); err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.Analyze", err)
+		return nil, err
+	}
+
+	if err := a.discoverModules(); err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.Analyze", err)
+		return nil, err
+	}
+
+	if a.typed() {
+		err = a.loadTyped()
+		if err != nil {
+			tracer.ExitError("analyzer.GoAnalyzer.Analyze", err)
+			return nil, err
+		}
+	} else {
+		for _, root := range a.roots {
+			err = filepath.Walk(root, a.walkFunc)
+			if err != nil {
+				tracer.ExitError("analyzer.GoAnalyzer.Analyze", err)
+				return nil, fmt.Errorf("failed to walk directory: %w", err)
+			}
+		}
+
+		err = a.parseFiles()
+		if err != nil {
+			tracer.ExitError("analyzer.GoAnalyzer.Analyze", err)
+			return nil, err
+		}
 	}
 
 	a.buildGraph()
@@ -127,28 +296,39 @@ func (a *GoAnalyzer) Analyze(dir string) (*model.Graph, error) {
 	return graph, nil
 }
 
-func (a *GoAnalyzer) detectModulePath() string {
-	tracer.Enter("analyzer.GoAnalyzer.detectModulePath")
+// typed reports whether packages are loaded with go/packages and
+// type-checked rather than parsed file by file.
+func (a *GoAnalyzer) typed() bool {
+	return a.opts.TypeCheck || a.usesSSA()
+}
 
-	goModPath := filepath.Join(a.baseDir, "go.mod")
-	data, err := os.ReadFile(goModPath)
-	if err != nil {
-		tracer.ExitSuccess("analyzer.GoAnalyzer.detectModulePath")
-		return ""
+// ModulePath returns the path of the module containing the analyzed
+// directory once Analyze has run, or "" outside modules and at the root
+// of a go.work workspace.
+func (a *GoAnalyzer) ModulePath() string {
+	return a.modulePath
+}
+
+// Modules returns the sorted paths of every analyzed module once Analyze
+// has run.
+func (a *GoAnalyzer) Modules() []string {
+	paths := make([]string, 0, len(a.modules))
+	for _, mod := range a.modules {
+		paths = append(paths, mod.Path)
 	}
+	sort.Strings(paths)
 
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "module ") {
-			modulePath := strings.TrimPrefix(line, "module ")
-			tracer.ExitSuccess("analyzer.GoAnalyzer.detectModulePath")
-			return strings.TrimSpace(modulePath)
+	return paths
+}
+
+func (a *GoAnalyzer) isRoot(path string) bool {
+	for _, root := range a.roots {
+		if path == root {
+			return true
 		}
 	}
 
-	tracer.ExitSuccess("analyzer.GoAnalyzer.detectModulePath")
-	return ""
+	return false
 }
 
 func (a *GoAnalyzer) walkFunc(path string, info os.FileInfo, err error) error {
@@ -160,8 +340,7 @@ func (a *GoAnalyzer) walkFunc(path string, info os.FileInfo, err error) error {
 	}
 
 	if info.IsDir() {
-		name := info.Name()
-		if name == "vendor" || name == "node_modules" || name == ".git" || name == "bin" {
+		if skipDir(info.Name()) && !a.isRoot(path) {
 			tracer.ExitSuccess("analyzer.GoAnalyzer.walkFunc")
 			return filepath.SkipDir
 		}
@@ -174,70 +353,113 @@ func (a *GoAnalyzer) walkFunc(path string, info os.FileInfo, err error) error {
 		return nil
 	}
 
-	if strings.HasSuffix(path, "_test.go") {
+	if isTestFile(path) && !a.opts.IncludeTests {
 		tracer.ExitSuccess("analyzer.GoAnalyzer.walkFunc")
 		return nil
 	}
 
-	parseErr := a.parseFile(path)
-	if parseErr != nil {
-		tracer.ExitError("analyzer.GoAnalyzer.walkFunc", parseErr)
-		return parseErr
+	matched, platforms, err := a.matchFile(path)
+	if err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.walkFunc", err)
+		return err
 	}
 
+	if !matched {
+		tracer.ExitSuccess("analyzer.GoAnalyzer.walkFunc")
+		return nil
+	}
+
+	a.files = append(a.files, fileTask{path: path, platforms: platforms})
+
 	tracer.ExitSuccess("analyzer.GoAnalyzer.walkFunc")
 	return nil
 }
 
-func (a *GoAnalyzer) parseFile(filename string) error {
+// parseFile extracts the declarations of a single file. It only reads
+// analyzer state, so files can be parsed concurrently. When src is nil the
+// file is read from disk.
+func (a *GoAnalyzer) parseFile(filename string, src []byte, platforms []string) (*fileResult, error) {
 	tracer.Enter("analyzer.GoAnalyzer.parseFile")
 
+	var source any
+	if src != nil {
+		source = src
+	}
+
 	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	node, err := parser.ParseFile(fset, filename, source, parser.ParseComments|parser.SkipObjectResolution)
 	if err != nil {
 		tracer.ExitError("analyzer.GoAnalyzer.parseFile", err)
-		return fmt.Errorf("failed to parse file %s: %w", filename, err)
+		return nil, fmt.Errorf("failed to parse file %s: %w", filename, err)
 	}
 
 	dir := filepath.Dir(filename)
-	relDir, _ := filepath.Rel(a.baseDir, dir)
-	pkgPath := a.modulePath
-	if relDir != "." && relDir != "" {
-		pkgPath = a.modulePath + "/" + relDir
+	pkgPath, mod := a.packagePath(dir)
+	if strings.HasSuffix(node.Name.Name, "_test") {
+		pkgPath += "_test"
 	}
 
-	if _, exists := a.packages[pkgPath]; !exists {
-		a.packages[pkgPath] = &PackageInfo{
-			Name:    node.Name.Name,
-			Path:    pkgPath,
-			Dir:     dir,
-			Imports: []string{},
-		}
+	imports := newImportTable(node)
+
+	result := newFileResult()
+	result.pkg = &PackageInfo{
+		Name:       node.Name.Name,
+		Path:       pkgPath,
+		Dir:        dir,
+		Doc:        docSummary(node.Doc),
+		Imports:    []string{},
+		DotImports: imports.dot,
+		Platforms:  platforms,
+		Uses:       collectImportUses(node, imports, nil),
+	}
+	if mod != nil {
+		result.pkg.Module = mod.Path
 	}
 
 	for _, imp := range node.Imports {
 		impPath := strings.Trim(imp.Path.Value, "\"")
 		if !a.isStdLib(impPath) {
-			a.packages[pkgPath].Imports = append(a.packages[pkgPath].Imports, impPath)
+			result.pkg.Imports = append(result.pkg.Imports, impPath)
 		}
 	}
 
-	for _, decl := range node.Decls {
+	a.parseDecls(node, &fileContext{
+		fset:      fset,
+		pkgPath:   pkgPath,
+		filename:  filename,
+		platforms: platforms,
+		imports:   imports,
+		result:    result,
+	})
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.parseFile")
+	return result, nil
+}
+
+func (a *GoAnalyzer) parseDecls(file *ast.File, fc *fileContext) {
+	tracer.Enter("analyzer.GoAnalyzer.parseDecls")
+
+	for _, decl := range file.Decls {
 		switch d := decl.(type) {
 		case *ast.GenDecl:
-			a.parseGenDecl(d, pkgPath, filename, fset)
+			a.parseGenDecl(d, fc)
 		case *ast.FuncDecl:
-			a.parseFuncDecl(d, pkgPath, filename, fset)
+			a.parseFuncDecl(d, fc)
 		}
 	}
 
-	tracer.ExitSuccess("analyzer.GoAnalyzer.parseFile")
-	return nil
+	tracer.ExitSuccess("analyzer.GoAnalyzer.parseDecls")
 }
 
-func (a *GoAnalyzer) parseGenDecl(decl *ast.GenDecl, pkgPath, filename string, fset *token.FileSet) {
+func (a *GoAnalyzer) parseGenDecl(decl *ast.GenDecl, fc *fileContext) {
 	tracer.Enter("analyzer.GoAnalyzer.parseGenDecl")
 
+	if decl.Tok == token.VAR || decl.Tok == token.CONST {
+		a.parseValueDecl(decl, fc)
+		tracer.ExitSuccess("analyzer.GoAnalyzer.parseGenDecl")
+		return
+	}
+
 	if decl.Tok != token.TYPE {
 		tracer.ExitSuccess("analyzer.GoAnalyzer.parseGenDecl")
 		return
@@ -249,138 +471,450 @@ func (a *GoAnalyzer) parseGenDecl(decl *ast.GenDecl, pkgPath, filename string, f
 			continue
 		}
 
-		typeID := pkgPath + "." + typeSpec.Name.Name
-		pos := fset.Position(typeSpec.Pos())
+		typeID := fc.pkgPath + "." + typeSpec.Name.Name
+		pos := fc.fset.Position(typeSpec.Pos())
+		fc.typeParams = typeParamNames(typeSpec.TypeParams)
+
+		var declDoc *ast.CommentGroup
+		if len(decl.Specs) == 1 {
+			declDoc = decl.Doc
+		}
 
 		typeInfo := &TypeInfo{
-			Name:    typeSpec.Name.Name,
-			Package: pkgPath,
-			File:    filename,
-			Line:    pos.Line,
-			Fields:  []FieldInfo{},
-			Embeds:  []string{},
+			Name:      typeSpec.Name.Name,
+			Package:   fc.pkgPath,
+			File:      fc.filename,
+			Line:      pos.Line,
+			EndLine:   fc.fset.Position(typeSpec.End()).Line,
+			Doc:       docSummary(typeSpec.Doc, declDoc),
+			Fields:    []FieldInfo{},
+			Embeds:    []FieldInfo{},
+			Platforms: fc.platforms,
 		}
+		typeInfo.TypeParams = a.collectTypeParams(typeSpec.TypeParams, fc)
+		typeInfo.Instances = a.collectInstances(typeSpec.Type, fc)
+
+		typeInfo.Kind = a.typeKind(typeSpec)
 
 		switch t := typeSpec.Type.(type) {
 		case *ast.StructType:
-			typeInfo.Kind = "struct"
 			if t.Fields != nil {
 				for _, field := range t.Fields.List {
-					a.parseStructField(field, typeInfo, pkgPath)
+					a.parseStructField(field, typeInfo, fc)
 				}
 			}
 		case *ast.InterfaceType:
-			typeInfo.Kind = "interface"
+			if t.Methods != nil {
+				for _, field := range t.Methods.List {
+					a.parseInterfaceMethod(field, typeInfo, fc)
+				}
+			}
+		default:
+			typeInfo.Underlying = types.ExprString(typeSpec.Type)
+			a.parseUnderlyingType(typeSpec.Type, typeInfo, fc)
 		}
 
-		a.types[typeID] = typeInfo
+		addType(fc.result.types, typeID, typeInfo)
 	}
+	fc.typeParams = nil
 
 	tracer.ExitSuccess("analyzer.GoAnalyzer.parseGenDecl")
 }
 
-func (a *GoAnalyzer) parseStructField(field *ast.Field, typeInfo *TypeInfo, pkgPath string) {
+// typeKind classifies a type declaration by the syntax of its type
+// expression. Defined types whose underlying type comes from another
+// named type are "named".
+func (a *GoAnalyzer) typeKind(typeSpec *ast.TypeSpec) string {
+	if typeSpec.Assign.IsValid() {
+		return "alias"
+	}
+
+	expr := typeSpec.Type
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+
+	switch t := expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "functype"
+	case *ast.MapType:
+		return "map"
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "slice"
+		}
+		return "array"
+	case *ast.ChanType:
+		return "chan"
+	case *ast.StarExpr:
+		return "pointer"
+	case *ast.Ident:
+		if a.isBasicType(t.Name) {
+			return "basic"
+		}
+	}
+
+	return "named"
+}
+
+// parseUnderlyingType records the named type a non-struct declaration is
+// built from, e.g. Context in type Contexts map[string]Context, as a
+// dependency of the declared type.
+func (a *GoAnalyzer) parseUnderlyingType(expr ast.Expr, typeInfo *TypeInfo, fc *fileContext) {
+	typeName, typePkg := a.resolveTypeName(expr, fc)
+	if typeName == "" || (typeName == typeInfo.Name && typePkg == typeInfo.Package) {
+		return
+	}
+
+	typeInfo.Fields = append(typeInfo.Fields, FieldInfo{
+		TypeName: typeName,
+		TypePkg:  typePkg,
+	})
+}
+
+func (a *GoAnalyzer) parseStructField(field *ast.Field, typeInfo *TypeInfo, fc *fileContext) {
 	tracer.Enter("analyzer.GoAnalyzer.parseStructField")
 
-	typeName, typePkg := a.resolveTypeName(field.Type, pkgPath)
+	typeName, typePkg := a.resolveTypeName(field.Type, fc)
 
 	if len(field.Names) == 0 {
-		typeInfo.Embeds = append(typeInfo.Embeds, typeName)
+		typeInfo.Embeds = append(typeInfo.Embeds, FieldInfo{
+			Name:     typeName,
+			TypeName: typeName,
+			TypePkg:  typePkg,
+		})
 		tracer.ExitSuccess("analyzer.GoAnalyzer.parseStructField")
 		return
 	}
 
+	chanType := a.chanType(field.Type, fc)
+
 	for _, name := range field.Names {
 		typeInfo.Fields = append(typeInfo.Fields, FieldInfo{
 			Name:     name.Name,
 			TypeName: typeName,
 			TypePkg:  typePkg,
 		})
+
+		if chanType != "" {
+			typeInfo.Channels = append(typeInfo.Channels, ChannelInfo{
+				Name: name.Name,
+				Type: chanType,
+				Line: fc.fset.Position(name.Pos()).Line,
+			})
+		}
 	}
 
 	tracer.ExitSuccess("analyzer.GoAnalyzer.parseStructField")
 }
 
-func (a *GoAnalyzer) resolveTypeName(expr ast.Expr, currentPkg string) (string, string) {
+func (a *GoAnalyzer) parseInterfaceMethod(field *ast.Field, typeInfo *TypeInfo, fc *fileContext) {
+	tracer.Enter("analyzer.GoAnalyzer.parseInterfaceMethod")
+
+	funcType, ok := field.Type.(*ast.FuncType)
+	if !ok {
+		typeName, typePkg := a.resolveTypeName(field.Type, fc)
+		if typeName != "" {
+			typeInfo.Embeds = append(typeInfo.Embeds, FieldInfo{
+				Name:     typeName,
+				TypeName: typeName,
+				TypePkg:  typePkg,
+			})
+		}
+		tracer.ExitSuccess("analyzer.GoAnalyzer.parseInterfaceMethod")
+		return
+	}
+
+	for _, name := range field.Names {
+		pos := fc.fset.Position(name.Pos())
+		methodID := fc.pkgPath + "." + typeInfo.Name + "." + name.Name
+
+		addMethod(fc.result.methods, methodID, &MethodInfo{
+			Name:      name.Name,
+			Receiver:  typeInfo.Name,
+			Package:   fc.pkgPath,
+			File:      fc.filename,
+			Line:      pos.Line,
+			EndLine:   fc.fset.Position(field.End()).Line,
+			Doc:       docSummary(field.Doc, field.Comment),
+			Signature: signatureString(funcType, fc),
+			Decl:      interfaceMethodString(fc.fset, name.Name, funcType),
+			Calls:     []CallInfo{},
+			Params:    a.collectParams(funcType, fc),
+			Results:   a.collectResults(funcType, fc),
+			Platforms: fc.platforms,
+		})
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.parseInterfaceMethod")
+}
+
+func (a *GoAnalyzer) resolveTypeName(expr ast.Expr, fc *fileContext) (string, string) {
 	tracer.Enter("analyzer.GoAnalyzer.resolveTypeName")
 
+	if fc.info != nil {
+		if t := fc.info.TypeOf(expr); t != nil {
+			typeName, typePkg := namedTypeOf(t)
+			tracer.ExitSuccess("analyzer.GoAnalyzer.resolveTypeName")
+			return typeName, typePkg
+		}
+	}
+
 	var typeName, typePkg string
 
 	switch t := expr.(type) {
 	case *ast.Ident:
-		typeName = t.Name
-		typePkg = currentPkg
+		if !fc.typeParams[t.Name] {
+			typeName = t.Name
+			typePkg = fc.pkgPath
+		}
 	case *ast.SelectorExpr:
 		if ident, ok := t.X.(*ast.Ident); ok {
 			typePkg = ident.Name
+			if impPath, ok := fc.imports.resolve(ident.Name); ok {
+				typePkg = impPath
+			}
 			typeName = t.Sel.Name
 		}
 	case *ast.StarExpr:
-		typeName, typePkg = a.resolveTypeName(t.X, currentPkg)
+		typeName, typePkg = a.resolveTypeName(t.X, fc)
 	case *ast.ArrayType:
-		typeName, typePkg = a.resolveTypeName(t.Elt, currentPkg)
+		typeName, typePkg = a.resolveTypeName(t.Elt, fc)
 	case *ast.MapType:
-		typeName, typePkg = a.resolveTypeName(t.Value, currentPkg)
+		typeName, typePkg = a.resolveTypeName(t.Value, fc)
+	case *ast.ChanType:
+		typeName, typePkg = a.resolveTypeName(t.Value, fc)
+	case *ast.Ellipsis:
+		typeName, typePkg = a.resolveTypeName(t.Elt, fc)
+	case *ast.IndexExpr:
+		typeName, typePkg = a.resolveTypeName(t.X, fc)
+	case *ast.IndexListExpr:
+		typeName, typePkg = a.resolveTypeName(t.X, fc)
 	}
 
 	tracer.ExitSuccess("analyzer.GoAnalyzer.resolveTypeName")
 	return typeName, typePkg
 }
 
-func (a *GoAnalyzer) parseFuncDecl(decl *ast.FuncDecl, pkgPath, filename string, fset *token.FileSet) {
+func (a *GoAnalyzer) parseFuncDecl(decl *ast.FuncDecl, fc *fileContext) {
 	tracer.Enter("analyzer.GoAnalyzer.parseFuncDecl")
 
-	pos := fset.Position(decl.Pos())
+	pos := fc.fset.Position(decl.Pos())
 
 	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		fc.typeParams = receiverTypeParams(decl.Recv.List[0].Type)
 		receiver := a.getReceiverName(decl.Recv.List[0].Type)
-		methodID := pkgPath + "." + receiver + "." + decl.Name.Name
+		methodID := fc.pkgPath + "." + receiver + "." + decl.Name.Name
 
 		methodInfo := &MethodInfo{
-			Name:     decl.Name.Name,
-			Receiver: receiver,
-			Package:  pkgPath,
-			File:     filename,
-			Line:     pos.Line,
-			Calls:    []CallInfo{},
+			Name:       decl.Name.Name,
+			Receiver:   receiver,
+			Package:    fc.pkgPath,
+			File:       fc.filename,
+			Line:       pos.Line,
+			EndLine:    fc.fset.Position(decl.End()).Line,
+			Doc:        docSummary(decl.Doc),
+			Signature:  signatureString(decl.Type, fc),
+			Decl:       declarationString(fc.fset, decl),
+			Calls:      []CallInfo{},
+			Params:     a.collectParams(decl.Type, fc),
+			Results:    a.collectResults(decl.Type, fc),
+			BodyTypes:  a.collectBodyTypes(decl.Body, fc),
+			Instances:  a.collectDeclInstances(decl, fc),
+			VarRefs:    a.collectVarRefs(decl, fc),
+			InTestFile: isTestFile(fc.filename),
+			Platforms:  fc.platforms,
 		}
 
 		if decl.Body != nil {
-			methodInfo.Calls = a.collectCalls(decl.Body, fset)
+			scope := a.newLocalScope(decl, fc)
+			methodInfo.Calls = a.collectCalls(decl.Body, fc, scope)
+			methodInfo.ChanOps = a.collectChanOps(decl, fc, scope)
+			methodInfo.Refs = a.collectFuncRefs(decl.Body, localNames(decl), fc, scope)
 		}
 
-		a.methods[methodID] = methodInfo
+		addMethod(fc.result.methods, methodID, methodInfo)
 	} else {
-		funcID := pkgPath + "." + decl.Name.Name
+		funcID := fc.pkgPath + "." + decl.Name.Name
+		fc.typeParams = typeParamNames(decl.Type.TypeParams)
 
 		funcInfo := &FunctionInfo{
-			Name:    decl.Name.Name,
-			Package: pkgPath,
-			File:    filename,
-			Line:    pos.Line,
-			Calls:   []CallInfo{},
+			Name:       decl.Name.Name,
+			Package:    fc.pkgPath,
+			File:       fc.filename,
+			Line:       pos.Line,
+			EndLine:    fc.fset.Position(decl.End()).Line,
+			Doc:        docSummary(decl.Doc),
+			Decl:       declarationString(fc.fset, decl),
+			Calls:      []CallInfo{},
+			Params:     a.collectParams(decl.Type, fc),
+			Results:    a.collectResults(decl.Type, fc),
+			BodyTypes:  a.collectBodyTypes(decl.Body, fc),
+			TypeParams: a.collectTypeParams(decl.Type.TypeParams, fc),
+			Instances:  a.collectDeclInstances(decl, fc),
+			VarRefs:    a.collectVarRefs(decl, fc),
+			InTestFile: isTestFile(fc.filename),
+			Platforms:  fc.platforms,
+		}
+		if funcInfo.InTestFile {
+			funcInfo.TestKind = testKind(decl.Name.Name)
 		}
 
 		if decl.Body != nil {
-			funcInfo.Calls = a.collectCalls(decl.Body, fset)
+			scope := a.newLocalScope(decl, fc)
+			funcInfo.Calls = a.collectCalls(decl.Body, fc, scope)
+			funcInfo.ChanOps = a.collectChanOps(decl, fc, scope)
+			funcInfo.Refs = a.collectFuncRefs(decl.Body, localNames(decl), fc, scope)
 		}
 
-		a.functions[funcID] = funcInfo
+		addFunction(fc.result.functions, funcID, funcInfo)
 	}
+	fc.typeParams = nil
 
 	tracer.ExitSuccess("analyzer.GoAnalyzer.parseFuncDecl")
 }
 
+// collectDeclInstances returns the generic instantiations in the signature
+// and body of decl. The receiver is skipped: Cache[K, V] in a method
+// receiver names the generic type itself.
+func (a *GoAnalyzer) collectDeclInstances(decl *ast.FuncDecl, fc *fileContext) []InstanceInfo {
+	instances := a.collectInstances(decl.Type, fc)
+	if decl.Body != nil {
+		instances = append(instances, a.collectInstances(decl.Body, fc)...)
+	}
+
+	return instances
+}
+
+// collectBodyTypes returns the types named in a function body: composite
+// literals, local variable declarations, conversions, new and make, type
+// assertions, type switch cases and function literal signatures. Names
+// that are not types are filtered out when the graph is built.
+func (a *GoAnalyzer) collectBodyTypes(body *ast.BlockStmt, fc *fileContext) []FieldInfo {
+	tracer.Enter("analyzer.GoAnalyzer.collectBodyTypes")
+
+	if body == nil {
+		tracer.ExitSuccess("analyzer.GoAnalyzer.collectBodyTypes")
+		return nil
+	}
+
+	fields := []FieldInfo{}
+	seen := make(map[FieldInfo]bool)
+
+	add := func(expr ast.Expr) {
+		if expr == nil {
+			return
+		}
+		typeName, typePkg := a.resolveTypeName(expr, fc)
+		field := FieldInfo{TypeName: typeName, TypePkg: typePkg}
+		if typeName != "" && !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.CompositeLit:
+			add(e.Type)
+		case *ast.ValueSpec:
+			add(e.Type)
+		case *ast.TypeAssertExpr:
+			add(e.Type)
+		case *ast.TypeSwitchStmt:
+			for _, stmt := range e.Body.List {
+				if clause, ok := stmt.(*ast.CaseClause); ok {
+					for _, expr := range clause.List {
+						add(expr)
+					}
+				}
+			}
+		case *ast.FuncLit:
+			fields = append(fields, a.fieldListTypes(e.Type.Params, fc)...)
+			fields = append(fields, a.fieldListTypes(e.Type.Results, fc)...)
+		case *ast.CallExpr:
+			if ident, ok := e.Fun.(*ast.Ident); ok && (ident.Name == "new" || ident.Name == "make") {
+				if len(e.Args) > 0 {
+					add(e.Args[0])
+				}
+				return true
+			}
+			switch unwrapIndex(e.Fun).(type) {
+			case *ast.Ident, *ast.SelectorExpr, *ast.ParenExpr:
+				if fc.info == nil || fc.info.Types[e.Fun].IsType() {
+					add(e.Fun)
+				}
+			}
+		}
+		return true
+	})
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.collectBodyTypes")
+	return fields
+}
+
+func (a *GoAnalyzer) collectResults(funcType *ast.FuncType, fc *fileContext) []FieldInfo {
+	tracer.Enter("analyzer.GoAnalyzer.collectResults")
+
+	results := a.fieldListTypes(funcType.Results, fc)
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.collectResults")
+	return results
+}
+
+func (a *GoAnalyzer) collectParams(funcType *ast.FuncType, fc *fileContext) []FieldInfo {
+	tracer.Enter("analyzer.GoAnalyzer.collectParams")
+
+	params := a.fieldListTypes(funcType.Params, fc)
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.collectParams")
+	return params
+}
+
+// fieldListTypes resolves the types of a parameter or result list, one
+// entry per declared name.
+func (a *GoAnalyzer) fieldListTypes(list *ast.FieldList, fc *fileContext) []FieldInfo {
+	fields := []FieldInfo{}
+	if list == nil {
+		return fields
+	}
+
+	for _, field := range list.List {
+		typeName, typePkg := a.resolveTypeName(field.Type, fc)
+		info := FieldInfo{TypeName: typeName, TypePkg: typePkg}
+
+		if len(field.Names) == 0 {
+			fields = append(fields, info)
+			continue
+		}
+
+		for _, name := range field.Names {
+			info.Name = name.Name
+			fields = append(fields, info)
+		}
+	}
+
+	return fields
+}
+
 func (a *GoAnalyzer) getReceiverName(expr ast.Expr) string {
 	tracer.Enter("analyzer.GoAnalyzer.getReceiverName")
 
 	var name string
 
-	switch t := expr.(type) {
+	switch t := unwrapIndex(expr).(type) {
 	case *ast.Ident:
 		name = t.Name
 	case *ast.StarExpr:
-		if ident, ok := t.X.(*ast.Ident); ok {
+		if ident, ok := unwrapIndex(t.X).(*ast.Ident); ok {
 			name = ident.Name
 		}
 	}
@@ -389,10 +923,11 @@ func (a *GoAnalyzer) getReceiverName(expr ast.Expr) string {
 	return name
 }
 
-func (a *GoAnalyzer) collectCalls(body *ast.BlockStmt, fset *token.FileSet) []CallInfo {
+func (a *GoAnalyzer) collectCalls(body *ast.BlockStmt, fc *fileContext, scope *localScope) []CallInfo {
 	tracer.Enter("analyzer.GoAnalyzer.collectCalls")
 
 	var calls []CallInfo
+	kinds := callKinds(body)
 
 	ast.Inspect(body, func(n ast.Node) bool {
 		callExpr, ok := n.(*ast.CallExpr)
@@ -400,26 +935,10 @@ func (a *GoAnalyzer) collectCalls(body *ast.BlockStmt, fset *token.FileSet) []Ca
 			return true
 		}
 
-		pos := fset.Position(callExpr.Pos())
-
-		switch fun := callExpr.Fun.(type) {
-		case *ast.Ident:
-			if !a.isBuiltin(fun.Name) {
-				calls = append(calls, CallInfo{
-					Target:   fun.Name,
-					IsMethod: false,
-					Line:     pos.Line,
-				})
-			}
-		case *ast.SelectorExpr:
-			if ident, ok := fun.X.(*ast.Ident); ok {
-				calls = append(calls, CallInfo{
-					Target:   fun.Sel.Name,
-					IsMethod: true,
-					Receiver: ident.Name,
-					Line:     pos.Line,
-				})
-			}
+		if call, ok := a.callTarget(callExpr.Fun, fc, scope); ok {
+			call.Line = fc.fset.Position(callExpr.Pos()).Line
+			call.Kind = kinds[callExpr]
+			calls = append(calls, call)
 		}
 
 		return true
@@ -429,6 +948,44 @@ func (a *GoAnalyzer) collectCalls(body *ast.BlockStmt, fset *token.FileSet) []Ca
 	return calls
 }
 
+// callTarget describes the function or method fun names, whether it is
+// called or used as a value. It reports false for builtins and for
+// selectors whose receiver cannot be inferred.
+func (a *GoAnalyzer) callTarget(fun ast.Expr, fc *fileContext, scope *localScope) (CallInfo, bool) {
+	switch fun := unwrapIndex(fun).(type) {
+	case *ast.Ident:
+		if a.isBuiltin(fun.Name) {
+			return CallInfo{}, false
+		}
+		return CallInfo{
+			Target:   fun.Name,
+			IsMethod: false,
+			Resolved: calleeID(fun, fc.info),
+		}, true
+	case *ast.SelectorExpr:
+		call := CallInfo{
+			Target:       fun.Sel.Name,
+			IsMethod:     true,
+			ReceiverType: scope.typeOf(fun.X),
+			Resolved:     calleeID(fun, fc.info),
+		}
+		if ident, ok := fun.X.(*ast.Ident); ok {
+			call.Receiver = ident.Name
+			if impPath, ok := scope.packageRef(ident.Name); ok {
+				call.IsMethod = false
+				if call.Resolved == "" {
+					call.Resolved = impPath + "." + fun.Sel.Name
+				}
+			}
+		}
+		if call.Receiver != "" || call.ReceiverType != nil || call.Resolved != "" {
+			return call, true
+		}
+	}
+
+	return CallInfo{}, false
+}
+
 func (a *GoAnalyzer) isBuiltin(name string) bool {
 	builtins := map[string]bool{
 		"make": true, "new": true, "len": true, "cap": true,
@@ -439,6 +996,17 @@ func (a *GoAnalyzer) isBuiltin(name string) bool {
 	return builtins[name]
 }
 
+func (a *GoAnalyzer) isBasicType(name string) bool {
+	basicTypes := map[string]bool{
+		"bool": true, "string": true, "byte": true, "rune": true,
+		"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+		"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+		"uintptr": true, "float32": true, "float64": true,
+		"complex64": true, "complex128": true,
+	}
+	return basicTypes[name]
+}
+
 func (a *GoAnalyzer) isStdLib(importPath string) bool {
 	if !strings.Contains(importPath, ".") {
 		return true
@@ -468,14 +1036,31 @@ func (a *GoAnalyzer) isStdLib(importPath string) bool {
 func (a *GoAnalyzer) buildGraph() {
 	tracer.Enter("analyzer.GoAnalyzer.buildGraph")
 
+	a.computeImplements()
+	a.collectExternals()
+
 	a.buildPackageNodes()
+	a.buildModuleNodes()
 	a.buildTypeNodes()
 	a.buildFunctionNodes()
 	a.buildMethodNodes()
+	a.buildVarNodes()
+	a.buildChannelNodes()
+	a.buildExternalNodes()
 	a.buildImportEdges()
 	a.buildContainsEdges()
 	a.buildCallEdges()
+	a.buildReferenceEdges()
 	a.buildTypeDependencyEdges()
+	a.buildSignatureEdges()
+	if a.opts.BodyTypes {
+		a.buildBodyTypeEdges()
+	}
+	a.buildVarEdges()
+	a.buildChannelEdges()
+	a.buildImplementsEdges()
+	a.buildGenericEdges()
+	a.buildTestEdges()
 
 	tracer.ExitSuccess("analyzer.GoAnalyzer.buildGraph")
 }
@@ -485,9 +1070,11 @@ func (a *GoAnalyzer) buildPackageNodes() {
 
 	for path, pkg := range a.packages {
 		a.nodes = append(a.nodes, model.Node{
-			ID:     path,
-			Title:  pkg.Name,
-			Entity: "package",
+			ID:        path,
+			Title:     pkg.Name,
+			Entity:    "package",
+			Doc:       pkg.Doc,
+			Platforms: pkg.Platforms,
 		})
 	}
 
@@ -498,15 +1085,18 @@ func (a *GoAnalyzer) buildTypeNodes() {
 	tracer.Enter("analyzer.GoAnalyzer.buildTypeNodes")
 
 	for id, typeInfo := range a.types {
-		entity := "struct"
-		if typeInfo.Kind == "interface" {
-			entity = "interface"
-		}
-
 		a.nodes = append(a.nodes, model.Node{
-			ID:     id,
-			Title:  typeInfo.Name,
-			Entity: entity,
+			ID:         id,
+			Title:      typeInfo.Name,
+			Entity:     typeInfo.Kind,
+			Underlying: typeInfo.Underlying,
+			File:       a.sourceFile(typeInfo.File),
+			Line:       typeInfo.Line,
+			EndLine:    typeInfo.EndLine,
+			Exported:   exportedFlag(typeInfo.Name),
+			Doc:        typeInfo.Doc,
+			TypeParams: typeParamStrings(typeInfo.TypeParams),
+			Platforms:  typeInfo.Platforms,
 		})
 	}
 
@@ -517,10 +1107,23 @@ func (a *GoAnalyzer) buildFunctionNodes() {
 	tracer.Enter("analyzer.GoAnalyzer.buildFunctionNodes")
 
 	for id, funcInfo := range a.functions {
+		entity := "function"
+		if funcInfo.TestKind != "" {
+			entity = "test"
+		}
+
 		a.nodes = append(a.nodes, model.Node{
-			ID:     id,
-			Title:  funcInfo.Name,
-			Entity: "function",
+			ID:         id,
+			Title:      funcInfo.Name,
+			Entity:     entity,
+			File:       a.sourceFile(funcInfo.File),
+			Line:       funcInfo.Line,
+			EndLine:    funcInfo.EndLine,
+			Exported:   exportedFlag(funcInfo.Name),
+			Doc:        funcInfo.Doc,
+			Signature:  funcInfo.Decl,
+			TypeParams: typeParamStrings(funcInfo.TypeParams),
+			Platforms:  funcInfo.Platforms,
 		})
 	}
 
@@ -532,9 +1135,16 @@ func (a *GoAnalyzer) buildMethodNodes() {
 
 	for id, methodInfo := range a.methods {
 		a.nodes = append(a.nodes, model.Node{
-			ID:     id,
-			Title:  methodInfo.Name,
-			Entity: "method",
+			ID:        id,
+			Title:     methodInfo.Name,
+			Entity:    "method",
+			File:      a.sourceFile(methodInfo.File),
+			Line:      methodInfo.Line,
+			EndLine:   methodInfo.EndLine,
+			Exported:  exportedFlag(methodInfo.Name),
+			Doc:       methodInfo.Doc,
+			Signature: methodInfo.Decl,
+			Platforms: methodInfo.Platforms,
 		})
 	}
 
@@ -545,14 +1155,34 @@ func (a *GoAnalyzer) buildImportEdges() {
 	tracer.Enter("analyzer.GoAnalyzer.buildImportEdges")
 
 	for path, pkg := range a.packages {
+		edges := make(map[string]int)
+		seen := make(map[string]bool)
+
 		for _, imp := range pkg.Imports {
-			if strings.HasPrefix(imp, a.modulePath) {
+			if seen[imp] {
+				continue
+			}
+			seen[imp] = true
+
+			target := imp
+			if !a.isInternalImport(imp) {
+				target = a.externalTarget(imp)
+			}
+
+			index, exists := edges[target]
+			if !exists {
+				index = len(a.edges)
+				edges[target] = index
 				a.edges = append(a.edges, model.Edge{
 					From: path,
-					To:   imp,
+					To:   target,
 					Type: "import",
 				})
 			}
+
+			if a.opts.ImportDetail {
+				addImportDetail(&a.edges[index], imp, pkg.Uses[imp])
+			}
 		}
 	}
 
@@ -595,6 +1225,12 @@ func (a *GoAnalyzer) buildContainsEdges() {
 func (a *GoAnalyzer) buildCallEdges() {
 	tracer.Enter("analyzer.GoAnalyzer.buildCallEdges")
 
+	if a.usesSSA() {
+		a.buildSSACallEdges()
+		tracer.ExitSuccess("analyzer.GoAnalyzer.buildCallEdges")
+		return
+	}
+
 	for id, funcInfo := range a.functions {
 		for _, call := range funcInfo.Calls {
 			target := a.resolveCallTarget(call, funcInfo.Package)
@@ -602,7 +1238,7 @@ func (a *GoAnalyzer) buildCallEdges() {
 				a.edges = append(a.edges, model.Edge{
 					From:   id,
 					To:     target,
-					Type:   "calls",
+					Type:   callEdgeType(call.Kind),
 					Method: call.Target,
 				})
 			}
@@ -616,7 +1252,7 @@ func (a *GoAnalyzer) buildCallEdges() {
 				a.edges = append(a.edges, model.Edge{
 					From:   id,
 					To:     target,
-					Type:   "calls",
+					Type:   callEdgeType(call.Kind),
 					Method: call.Target,
 				})
 			}
@@ -629,6 +1265,19 @@ func (a *GoAnalyzer) buildCallEdges() {
 func (a *GoAnalyzer) resolveCallTarget(call CallInfo, currentPkg string) string {
 	tracer.Enter("analyzer.GoAnalyzer.resolveCallTarget")
 
+	if call.Resolved != "" {
+		if _, exists := a.functions[call.Resolved]; exists {
+			tracer.ExitSuccess("analyzer.GoAnalyzer.resolveCallTarget")
+			return call.Resolved
+		}
+		if _, exists := a.methods[call.Resolved]; exists {
+			tracer.ExitSuccess("analyzer.GoAnalyzer.resolveCallTarget")
+			return call.Resolved
+		}
+		tracer.ExitSuccess("analyzer.GoAnalyzer.resolveCallTarget")
+		return ""
+	}
+
 	if !call.IsMethod {
 		funcID := currentPkg + "." + call.Target
 		if _, exists := a.functions[funcID]; exists {
@@ -637,6 +1286,15 @@ func (a *GoAnalyzer) resolveCallTarget(call CallInfo, currentPkg string) string
 		}
 	}
 
+	if call.IsMethod && call.ReceiverType != nil {
+		typeID := a.resolveTypeRef(*call.ReceiverType)
+		if typeID != "" {
+			methodID := a.findMethod(typeID, call.Target, map[string]bool{})
+			tracer.ExitSuccess("analyzer.GoAnalyzer.resolveCallTarget")
+			return methodID
+		}
+	}
+
 	tracer.ExitSuccess("analyzer.GoAnalyzer.resolveCallTarget")
 	return ""
 }
@@ -654,12 +1312,11 @@ func (a *GoAnalyzer) buildTypeDependencyEdges() {
 
 	for id, typeInfo := range a.types {
 		for _, embed := range typeInfo.Embeds {
-			if primitives[embed] {
+			if primitives[embed.TypeName] {
 				continue
 			}
 
-			embedID := typeInfo.Package + "." + embed
-			if _, exists := a.types[embedID]; exists {
+			if embedID := a.typeDependencyTarget(embed); embedID != "" {
 				a.edges = append(a.edges, model.Edge{
 					From: id,
 					To:   embedID,
@@ -668,27 +1325,144 @@ func (a *GoAnalyzer) buildTypeDependencyEdges() {
 			}
 		}
 
+		seen := make(map[string]bool)
 		for _, field := range typeInfo.Fields {
 			if primitives[field.TypeName] {
 				continue
 			}
 
-			var depID string
-			if field.TypePkg == typeInfo.Package {
-				depID = typeInfo.Package + "." + field.TypeName
+			depID := a.typeDependencyTarget(field)
+			if depID == "" || seen[depID] {
+				continue
 			}
+			seen[depID] = true
 
-			if depID != "" {
-				if _, exists := a.types[depID]; exists {
-					a.edges = append(a.edges, model.Edge{
-						From: id,
-						To:   depID,
-						Type: "uses",
-					})
-				}
-			}
+			a.edges = append(a.edges, model.Edge{
+				From: id,
+				To:   depID,
+				Type: "uses",
+			})
 		}
 	}
 
 	tracer.ExitSuccess("analyzer.GoAnalyzer.buildTypeDependencyEdges")
 }
+
+// buildSignatureEdges links functions and methods to the types they accept
+// as receiver or parameters ("accepts") and the types they return
+// ("returns").
+func (a *GoAnalyzer) buildSignatureEdges() {
+	tracer.Enter("analyzer.GoAnalyzer.buildSignatureEdges")
+
+	for id, funcInfo := range a.functions {
+		a.addSignatureEdges(id, funcInfo.Params, "accepts")
+		a.addSignatureEdges(id, funcInfo.Results, "returns")
+	}
+
+	for id, methodInfo := range a.methods {
+		params := methodInfo.Params
+		receiverID := a.lookupTypeID(methodInfo.Package, methodInfo.Receiver)
+		if receiver, exists := a.types[receiverID]; exists && receiver.Kind != "interface" {
+			params = append([]FieldInfo{{TypeName: methodInfo.Receiver, TypePkg: methodInfo.Package}}, params...)
+		}
+
+		a.addSignatureEdges(id, params, "accepts")
+		a.addSignatureEdges(id, methodInfo.Results, "returns")
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildSignatureEdges")
+}
+
+// buildBodyTypeEdges links functions and methods to the collected types
+// named in their bodies with "mentions" edges. A method's own receiver type is
+// skipped.
+func (a *GoAnalyzer) buildBodyTypeEdges() {
+	tracer.Enter("analyzer.GoAnalyzer.buildBodyTypeEdges")
+
+	for id, funcInfo := range a.functions {
+		a.addBodyTypeEdges(id, "", funcInfo.BodyTypes)
+	}
+
+	for id, methodInfo := range a.methods {
+		receiverID := a.lookupTypeID(methodInfo.Package, methodInfo.Receiver)
+		a.addBodyTypeEdges(id, receiverID, methodInfo.BodyTypes)
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildBodyTypeEdges")
+}
+
+func (a *GoAnalyzer) addBodyTypeEdges(from, skip string, fields []FieldInfo) {
+	seen := make(map[string]bool)
+
+	for _, field := range fields {
+		target := a.lookupTypeID(field.TypePkg, field.TypeName)
+		if target == "" || target == skip || seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		a.edges = append(a.edges, model.Edge{
+			From: from,
+			To:   target,
+			Type: "mentions",
+		})
+	}
+}
+
+// signatureFieldLists returns the parameter and result lists of all
+// functions and methods.
+func (a *GoAnalyzer) signatureFieldLists() [][]FieldInfo {
+	lists := make([][]FieldInfo, 0, 2*(len(a.functions)+len(a.methods)))
+
+	for _, funcInfo := range a.functions {
+		lists = append(lists, funcInfo.Params, funcInfo.Results)
+	}
+
+	for _, methodInfo := range a.methods {
+		lists = append(lists, methodInfo.Params, methodInfo.Results)
+	}
+
+	return lists
+}
+
+func (a *GoAnalyzer) addSignatureEdges(from string, fields []FieldInfo, edgeType string) {
+	seen := make(map[string]bool)
+
+	for _, field := range fields {
+		target := a.typeDependencyTarget(field)
+		if target == "" || seen[target] {
+			continue
+		}
+		seen[target] = true
+
+		a.edges = append(a.edges, model.Edge{
+			From: from,
+			To:   target,
+			Type: edgeType,
+		})
+	}
+}
+
+// typeDependencyTarget returns the component a field or embedded type
+// depends on: a collected type, or the external component of a type
+// declared outside the module. Standard library types yield "".
+func (a *GoAnalyzer) typeDependencyTarget(field FieldInfo) string {
+	if field.TypePkg == "" {
+		return ""
+	}
+
+	if typeID := a.lookupTypeID(field.TypePkg, field.TypeName); typeID != "" {
+		return typeID
+	}
+
+	if a.isExternalPackage(field.TypePkg) {
+		return a.externalTarget(field.TypePkg)
+	}
+
+	return ""
+}
+
+// isExternalPackage reports whether importPath is a third-party package.
+func (a *GoAnalyzer) isExternalPackage(importPath string) bool {
+	return importPath != "" && !a.isStdLib(importPath) && !a.isInternalImport(importPath)
+}
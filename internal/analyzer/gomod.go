@@ -0,0 +1,253 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// ModuleRequirement holds a require directive of go.mod together with the
+// replace directive that applies to it, if any.
+type ModuleRequirement struct {
+	Path     string
+	Version  string
+	Indirect bool
+	Replace  string // replacement path, with @version for module replacements
+}
+
+// ExternalInfo holds information about a component outside the analyzed
+// module: a required module or one of its imported packages.
+type ExternalInfo struct {
+	ID      string
+	Title   string
+	Module  string // owning module path; equals ID for module components
+	Version string
+	Replace string
+}
+
+// loadGoMod parses the go.mod file in dir, records its require and
+// replace directives and returns the module it declares. A missing go.mod
+// is not an error and yields a nil module.
+func (a *GoAnalyzer) loadGoMod(dir string) (*ModuleInfo, error) {
+	tracer.Enter("analyzer.GoAnalyzer.loadGoMod")
+
+	goModPath := filepath.Join(dir, "go.mod")
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		tracer.ExitSuccess("analyzer.GoAnalyzer.loadGoMod")
+		return nil, nil
+	}
+
+	file, err := modfile.Parse(goModPath, data, nil)
+	if err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.loadGoMod", err)
+		return nil, fmt.Errorf("failed to parse %s: %w", goModPath, err)
+	}
+
+	requires := make(map[string]*ModuleRequirement, len(file.Require))
+	for _, req := range file.Require {
+		requires[req.Mod.Path] = &ModuleRequirement{
+			Path:     req.Mod.Path,
+			Version:  req.Mod.Version,
+			Indirect: req.Indirect,
+		}
+	}
+
+	for _, rep := range file.Replace {
+		req, exists := requires[rep.Old.Path]
+		if !exists {
+			continue
+		}
+		if rep.Old.Version != "" && rep.Old.Version != req.Version {
+			continue
+		}
+
+		req.Replace = rep.New.Path
+		if rep.New.Version != "" {
+			req.Replace += "@" + rep.New.Version
+		}
+	}
+
+	// The first module requiring a path decides its version.
+	for modPath, req := range requires {
+		if _, exists := a.requires[modPath]; !exists {
+			a.requires[modPath] = req
+		}
+	}
+
+	mod := &ModuleInfo{Dir: dir}
+	if file.Module != nil {
+		mod.Path = file.Module.Mod.Path
+	}
+	if file.Go != nil {
+		mod.GoVersion = file.Go.Version
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.loadGoMod")
+	return mod, nil
+}
+
+// isInternalImport reports whether importPath belongs to the analyzed code,
+// including the other modules of the analyzed tree or workspace.
+func (a *GoAnalyzer) isInternalImport(importPath string) bool {
+	if _, exists := a.packages[importPath]; exists {
+		return true
+	}
+
+	if a.moduleForImport(importPath) != nil {
+		return true
+	}
+
+	return a.modulePath != "" &&
+		(importPath == a.modulePath || strings.HasPrefix(importPath, a.modulePath+"/"))
+}
+
+// requirementFor returns the go.mod requirement providing importPath,
+// choosing the longest matching module path.
+func (a *GoAnalyzer) requirementFor(importPath string) *ModuleRequirement {
+	var best *ModuleRequirement
+
+	for modPath, req := range a.requires {
+		if importPath != modPath && !strings.HasPrefix(importPath, modPath+"/") {
+			continue
+		}
+		if best == nil || len(modPath) > len(best.Path) {
+			best = req
+		}
+	}
+
+	return best
+}
+
+// collectExternals creates external components for every directly required
+// module, for every third-party import of the analyzed packages and for
+// every third-party type used in fields and embeds.
+func (a *GoAnalyzer) collectExternals() {
+	tracer.Enter("analyzer.GoAnalyzer.collectExternals")
+
+	for _, req := range a.requires {
+		if !req.Indirect && a.moduleForImport(req.Path) == nil {
+			a.addExternalModule(req)
+		}
+	}
+
+	for _, pkg := range a.packages {
+		for _, imp := range pkg.Imports {
+			if a.isInternalImport(imp) {
+				continue
+			}
+			a.externalTarget(imp)
+		}
+	}
+
+	for _, typeInfo := range a.types {
+		for _, fields := range [][]FieldInfo{typeInfo.Fields, typeInfo.Embeds} {
+			for _, field := range fields {
+				if a.isExternalPackage(field.TypePkg) {
+					a.externalTarget(field.TypePkg)
+				}
+			}
+		}
+	}
+
+	for _, fields := range a.signatureFieldLists() {
+		for _, field := range fields {
+			if a.isExternalPackage(field.TypePkg) {
+				a.externalTarget(field.TypePkg)
+			}
+		}
+	}
+
+	for _, params := range a.typeParamLists() {
+		for _, param := range params {
+			if a.isExternalPackage(param.TypePkg) {
+				a.externalTarget(param.TypePkg)
+			}
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.collectExternals")
+}
+
+func (a *GoAnalyzer) addExternalModule(req *ModuleRequirement) *ExternalInfo {
+	if ext, exists := a.externals[req.Path]; exists {
+		return ext
+	}
+
+	ext := &ExternalInfo{
+		ID:      req.Path,
+		Title:   req.Path,
+		Module:  req.Path,
+		Version: req.Version,
+		Replace: req.Replace,
+	}
+	a.externals[ext.ID] = ext
+
+	return ext
+}
+
+// externalTarget returns the ID of the external component an import of
+// importPath links to, creating it on first use. Imports not covered by
+// go.mod get a component of their own without version.
+func (a *GoAnalyzer) externalTarget(importPath string) string {
+	req := a.requirementFor(importPath)
+	if req == nil {
+		req = &ModuleRequirement{Path: importPath}
+	}
+
+	mod := a.addExternalModule(req)
+	if !a.opts.ExternalPackages || importPath == mod.ID {
+		return mod.ID
+	}
+
+	if _, exists := a.externals[importPath]; !exists {
+		a.externals[importPath] = &ExternalInfo{
+			ID:      importPath,
+			Title:   path.Base(importPath),
+			Module:  mod.ID,
+			Version: mod.Version,
+			Replace: mod.Replace,
+		}
+	}
+
+	return importPath
+}
+
+func (a *GoAnalyzer) buildExternalNodes() {
+	tracer.Enter("analyzer.GoAnalyzer.buildExternalNodes")
+
+	ids := make([]string, 0, len(a.externals))
+	for id := range a.externals {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		ext := a.externals[id]
+		a.nodes = append(a.nodes, model.Node{
+			ID:      ext.ID,
+			Title:   ext.Title,
+			Entity:  "external",
+			Version: ext.Version,
+			Replace: ext.Replace,
+		})
+
+		if ext.Module != ext.ID {
+			a.edges = append(a.edges, model.Edge{
+				From: ext.Module,
+				To:   ext.ID,
+				Type: "contains",
+			})
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildExternalNodes")
+}
@@ -0,0 +1,322 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// errorMethodSet is the method set of the predeclared error interface.
+var errorMethodSet = map[string]string{"Error": "() (string)"}
+
+// signatureString renders a function type without parameter names and
+// with package-level types qualified by their import path, e.g.
+// "(string) (*example.com/app/model.User, error)", so that signatures
+// written in different packages compare equal exactly when they denote
+// the same types. any is rendered as interface{}.
+func signatureString(funcType *ast.FuncType, fc *fileContext) string {
+	return "(" + fieldListString(funcType.Params, fc) + ") (" + fieldListString(funcType.Results, fc) + ")"
+}
+
+func fieldListString(fields *ast.FieldList, fc *fileContext) string {
+	if fields == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, field := range fields.List {
+		typeStr := typeExprString(field.Type, fc)
+
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			parts = append(parts, typeStr)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// typeExprString renders a type expression with package qualifiers
+// resolved through the import table of the file.
+func typeExprString(expr ast.Expr, fc *fileContext) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch {
+		case t.Name == "any":
+			return "interface{}"
+		case fc.typeParams[t.Name] || types.Universe.Lookup(t.Name) != nil:
+			return t.Name
+		}
+		return fc.pkgPath + "." + t.Name
+	case *ast.SelectorExpr:
+		if ident, ok := t.X.(*ast.Ident); ok {
+			if impPath, ok := fc.imports.resolve(ident.Name); ok {
+				return impPath + "." + t.Sel.Name
+			}
+		}
+		return types.ExprString(t)
+	case *ast.StarExpr:
+		return "*" + typeExprString(t.X, fc)
+	case *ast.ParenExpr:
+		return typeExprString(t.X, fc)
+	case *ast.Ellipsis:
+		return "..." + typeExprString(t.Elt, fc)
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "[]" + typeExprString(t.Elt, fc)
+		}
+		return "[" + types.ExprString(t.Len) + "]" + typeExprString(t.Elt, fc)
+	case *ast.MapType:
+		return "map[" + typeExprString(t.Key, fc) + "]" + typeExprString(t.Value, fc)
+	case *ast.ChanType:
+		switch t.Dir {
+		case ast.SEND:
+			return "chan<- " + typeExprString(t.Value, fc)
+		case ast.RECV:
+			return "<-chan " + typeExprString(t.Value, fc)
+		}
+		return "chan " + typeExprString(t.Value, fc)
+	case *ast.FuncType:
+		return "func" + signatureString(t, fc)
+	case *ast.IndexExpr:
+		return typeExprString(t.X, fc) + "[" + typeExprString(t.Index, fc) + "]"
+	case *ast.IndexListExpr:
+		args := make([]string, 0, len(t.Indices))
+		for _, index := range t.Indices {
+			args = append(args, typeExprString(index, fc))
+		}
+		return typeExprString(t.X, fc) + "[" + strings.Join(args, ", ") + "]"
+	}
+
+	return types.ExprString(expr)
+}
+
+// computeImplements fills TypeInfo.Implements for every non-interface type
+// whose method set covers the method set of a collected interface. In
+// typed mode the relation recorded by addTypedImplements is used instead.
+func (a *GoAnalyzer) computeImplements() {
+	tracer.Enter("analyzer.GoAnalyzer.computeImplements")
+
+	if a.typed() {
+		for id, typeInfo := range a.types {
+			typeInfo.Implements = nil
+			for ifaceID := range a.implements[id] {
+				typeInfo.Implements = append(typeInfo.Implements, ifaceID)
+			}
+			sort.Strings(typeInfo.Implements)
+		}
+		tracer.ExitSuccess("analyzer.GoAnalyzer.computeImplements")
+		return
+	}
+
+	declared := make(map[string]map[string]string)
+	for _, methodInfo := range a.methods {
+		typeID := methodInfo.Package + "." + methodInfo.Receiver
+		if declared[typeID] == nil {
+			declared[typeID] = make(map[string]string)
+		}
+		declared[typeID][methodInfo.Name] = methodInfo.Signature
+	}
+
+	interfaces := make(map[string]map[string]string)
+	for id, typeInfo := range a.types {
+		if typeInfo.Kind != "interface" {
+			continue
+		}
+		methodSet, complete := a.interfaceMethodSet(id, declared, map[string]bool{})
+		if complete && len(methodSet) > 0 {
+			interfaces[id] = methodSet
+		}
+	}
+
+	for id, typeInfo := range a.types {
+		if typeInfo.Kind == "interface" {
+			continue
+		}
+
+		methodSet := a.typeMethodSet(id, declared, map[string]bool{})
+		if len(methodSet) == 0 {
+			continue
+		}
+
+		typeInfo.Implements = nil
+		for ifaceID, ifaceMethods := range interfaces {
+			if coversMethodSet(methodSet, ifaceMethods) {
+				typeInfo.Implements = append(typeInfo.Implements, ifaceID)
+			}
+		}
+		sort.Strings(typeInfo.Implements)
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.computeImplements")
+}
+
+// interfaceMethodSet returns the method set of an interface including the
+// methods of embedded interfaces. complete is false when an embedded
+// interface is declared outside the analyzed code.
+func (a *GoAnalyzer) interfaceMethodSet(id string, declared map[string]map[string]string,
+	visited map[string]bool,
+) (map[string]string, bool) {
+	methodSet := make(map[string]string)
+	if visited[id] {
+		return methodSet, true
+	}
+	visited[id] = true
+
+	for name, signature := range declared[id] {
+		methodSet[name] = signature
+	}
+
+	complete := true
+	for _, embed := range a.types[id].Embeds {
+		if embed.TypeName == "error" && embed.TypePkg == "" {
+			for name, signature := range errorMethodSet {
+				methodSet[name] = signature
+			}
+			continue
+		}
+
+		embedID := embed.TypePkg + "." + embed.TypeName
+		if embedInfo, exists := a.types[embedID]; !exists || embedInfo.Kind != "interface" {
+			complete = false
+			continue
+		}
+
+		embedded, embeddedComplete := a.interfaceMethodSet(embedID, declared, visited)
+		complete = complete && embeddedComplete
+		for name, signature := range embedded {
+			methodSet[name] = signature
+		}
+	}
+
+	return methodSet, complete
+}
+
+// typeMethodSet returns the declared and promoted methods of a type.
+// Pointer and value receivers are not distinguished.
+func (a *GoAnalyzer) typeMethodSet(id string, declared map[string]map[string]string,
+	visited map[string]bool,
+) map[string]string {
+	methodSet := make(map[string]string)
+	if visited[id] {
+		return methodSet
+	}
+	visited[id] = true
+
+	typeInfo, exists := a.types[id]
+	if !exists {
+		return methodSet
+	}
+
+	for _, embed := range typeInfo.Embeds {
+		embedID := embed.TypePkg + "." + embed.TypeName
+
+		var promoted map[string]string
+		if embedInfo, ok := a.types[embedID]; ok && embedInfo.Kind == "interface" {
+			promoted, _ = a.interfaceMethodSet(embedID, declared, map[string]bool{})
+		} else {
+			promoted = a.typeMethodSet(embedID, declared, visited)
+		}
+
+		for name, signature := range promoted {
+			methodSet[name] = signature
+		}
+	}
+
+	for name, signature := range declared[id] {
+		methodSet[name] = signature
+	}
+
+	return methodSet
+}
+
+func coversMethodSet(methodSet, required map[string]string) bool {
+	for name, signature := range required {
+		if got, ok := methodSet[name]; !ok || got != signature {
+			return false
+		}
+	}
+
+	return true
+}
+
+// addTypedImplements records which collected types implement which
+// collected interfaces among the packages of one go/packages load, with
+// types.Implements on the value and the pointer type. Types of different
+// loads are never identical, so each load is checked on its own.
+func (a *GoAnalyzer) addTypedImplements(pkgs []*packages.Package) {
+	tracer.Enter("analyzer.GoAnalyzer.addTypedImplements")
+
+	var named, interfaces []*types.TypeName
+
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.Types == nil {
+			return
+		}
+
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			if _, collected := a.types[pkg.PkgPath+"."+name]; !collected {
+				continue
+			}
+
+			// The relation is unspecified for uninstantiated generic types.
+			if t, ok := obj.Type().(*types.Named); !ok || t.TypeParams().Len() > 0 {
+				continue
+			}
+
+			if iface, ok := obj.Type().Underlying().(*types.Interface); ok {
+				if iface.NumMethods() > 0 && iface.IsMethodSet() {
+					interfaces = append(interfaces, obj)
+				}
+				continue
+			}
+			named = append(named, obj)
+		}
+	})
+
+	for _, obj := range named {
+		typeID := obj.Pkg().Path() + "." + obj.Name()
+		for _, ifaceObj := range interfaces {
+			iface := ifaceObj.Type().Underlying().(*types.Interface)
+			if !types.Implements(obj.Type(), iface) && !types.Implements(types.NewPointer(obj.Type()), iface) {
+				continue
+			}
+			if a.implements[typeID] == nil {
+				a.implements[typeID] = make(map[string]bool)
+			}
+			a.implements[typeID][ifaceObj.Pkg().Path()+"."+ifaceObj.Name()] = true
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.addTypedImplements")
+}
+
+func (a *GoAnalyzer) buildImplementsEdges() {
+	tracer.Enter("analyzer.GoAnalyzer.buildImplementsEdges")
+
+	for id, typeInfo := range a.types {
+		for _, ifaceID := range typeInfo.Implements {
+			a.edges = append(a.edges, model.Edge{
+				From: id,
+				To:   ifaceID,
+				Type: "implements",
+			})
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildImplementsEdges")
+}
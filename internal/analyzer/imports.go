@@ -0,0 +1,200 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// importTable maps the names a file uses for its imports to import paths.
+type importTable struct {
+	byName map[string]string // alias or package name -> import path
+	dot    []string          // dot-imported paths
+}
+
+// newImportTable builds the import table of a file. Unnamed imports are
+// keyed by their guessed package name, see defaultImportName.
+func newImportTable(file *ast.File) *importTable {
+	tracer.Enter("analyzer.newImportTable")
+
+	table := &importTable{byName: make(map[string]string)}
+
+	for _, imp := range file.Imports {
+		impPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		name := defaultImportName(impPath)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+
+		switch name {
+		case "_":
+		case ".":
+			table.dot = append(table.dot, impPath)
+		default:
+			table.byName[name] = impPath
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.newImportTable")
+	return table
+}
+
+// resolve returns the import path for a package name used in the file.
+func (t *importTable) resolve(name string) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+
+	impPath, ok := t.byName[name]
+
+	return impPath, ok
+}
+
+// defaultImportName guesses the package name of an unnamed import the way
+// goimports does: the last path element without a major version suffix,
+// a "go-" prefix or a ".vN"-style extension.
+func defaultImportName(importPath string) string {
+	name := path.Base(importPath)
+
+	if isMajorVersion(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i > 0 {
+		name = name[:i]
+	}
+
+	return name
+}
+
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+
+	_, err := strconv.Atoi(elem[1:])
+
+	return err == nil
+}
+
+// lookupTypeID returns the ID of the collected type typeName declared in
+// typePkg. Types that are not declared in typePkg itself are looked up in
+// the packages typePkg dot-imports.
+func (a *GoAnalyzer) lookupTypeID(typePkg, typeName string) string {
+	typeID := typePkg + "." + typeName
+	if _, exists := a.types[typeID]; exists {
+		return typeID
+	}
+
+	if pkg, exists := a.packages[typePkg]; exists {
+		for _, dotPkg := range pkg.DotImports {
+			if _, found := a.types[dotPkg+"."+typeName]; found {
+				return dotPkg + "." + typeName
+			}
+		}
+	}
+
+	return ""
+}
+
+// collectImportUses counts the qualified identifiers of file, such as
+// store.Open, per import path and symbol. Without type information a
+// local variable shadowing a package name is counted as a use of the
+// package, and symbols of dot imports are not attributed.
+func collectImportUses(file *ast.File, imports *importTable, info *types.Info) map[string]map[string]int {
+	tracer.Enter("analyzer.collectImportUses")
+
+	uses := make(map[string]map[string]int)
+
+	add := func(impPath, name string) {
+		if uses[impPath] == nil {
+			uses[impPath] = make(map[string]int)
+		}
+		uses[impPath][name]++
+	}
+
+	dot := make(map[string]bool, len(imports.dot))
+	for _, impPath := range imports.dot {
+		dot[impPath] = true
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.SelectorExpr:
+			ident, ok := e.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+
+			if info == nil {
+				if impPath, ok := imports.resolve(ident.Name); ok {
+					add(impPath, e.Sel.Name)
+				}
+				return true
+			}
+
+			if pkgName, ok := info.Uses[ident].(*types.PkgName); ok {
+				add(pkgName.Imported().Path(), e.Sel.Name)
+				return false
+			}
+		case *ast.Ident:
+			if info == nil || len(dot) == 0 {
+				return true
+			}
+
+			obj := info.Uses[e]
+			if obj != nil && obj.Pkg() != nil && dot[obj.Pkg().Path()] && obj.Parent() == obj.Pkg().Scope() {
+				add(obj.Pkg().Path(), e.Name)
+			}
+		}
+		return true
+	})
+
+	tracer.ExitSuccess("analyzer.collectImportUses")
+	return uses
+}
+
+// mergeImportUses adds the import uses of a file to its package.
+func mergeImportUses(pkg *PackageInfo, uses map[string]map[string]int) {
+	if len(uses) > 0 && pkg.Uses == nil {
+		pkg.Uses = make(map[string]map[string]int, len(uses))
+	}
+
+	for impPath, symbols := range uses {
+		if pkg.Uses[impPath] == nil {
+			pkg.Uses[impPath] = make(map[string]int, len(symbols))
+		}
+		for name, count := range symbols {
+			pkg.Uses[impPath][name] += count
+		}
+	}
+}
+
+// addImportDetail adds the symbols used from impPath to an import edge.
+// When the edge targets the module providing impPath rather than the
+// package itself, symbols are qualified with the package path relative
+// to the module, e.g. "go/ssa.Program".
+func addImportDetail(edge *model.Edge, impPath string, symbols map[string]int) {
+	prefix := ""
+	if edge.To != impPath {
+		prefix = strings.TrimPrefix(impPath, edge.To+"/") + "."
+	}
+
+	for name, count := range symbols {
+		edge.Symbols = append(edge.Symbols, prefix+name)
+		edge.Count += count
+	}
+
+	sort.Strings(edge.Symbols)
+}
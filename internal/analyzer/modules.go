@@ -0,0 +1,365 @@
+package analyzer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// moduleIDPrefix distinguishes module components from the package at the
+// module root, which has the same import path.
+const moduleIDPrefix = "module:"
+
+// ModuleInfo holds information about a Go module of the analyzed tree.
+type ModuleInfo struct {
+	Path      string
+	Dir       string
+	GoVersion string
+	Workspace bool // listed in a use directive of go.work
+}
+
+func moduleNodeID(modulePath string) string {
+	return moduleIDPrefix + modulePath
+}
+
+// skipDir reports whether a directory is never part of the analyzed code:
+// the directories the go command ignores, testdata and names starting
+// with "." or "_", as well as vendored and build output trees. It applies
+// to the directory walk of syntactic mode and to the packages loaded in
+// typed mode alike.
+func skipDir(name string) bool {
+	return name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+		name == "vendor" || name == "node_modules" || name == "bin"
+}
+
+// skipPath reports whether dir lies below root in a directory skipDir
+// excludes.
+func skipPath(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		if skipDir(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// discoverModules finds every go.mod below baseDir, every module listed
+// in the go.work file of baseDir and the module baseDir lies in when it is
+// a directory inside a module. Workspace modules outside baseDir are added
+// to the analyzed roots. The module containing baseDir, if any, becomes
+// the module path of the analysis in both modes.
+func (a *GoAnalyzer) discoverModules() error {
+	tracer.Enter("analyzer.GoAnalyzer.discoverModules")
+
+	a.roots = []string{a.baseDir}
+
+	err := filepath.WalkDir(a.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != a.baseDir && skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+		_, err = a.addModule(filepath.Dir(path))
+		return err
+	})
+	if err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.discoverModules", err)
+		return fmt.Errorf("failed to discover modules: %w", err)
+	}
+
+	if err := a.loadGoWork(); err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.discoverModules", err)
+		return err
+	}
+
+	if err := a.addEnclosingModule(); err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.discoverModules", err)
+		return err
+	}
+
+	if mod := a.moduleForDir(a.baseDir); mod != nil {
+		a.modulePath = mod.Path
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.discoverModules")
+	return nil
+}
+
+// addEnclosingModule adds the module of the nearest go.mod above baseDir
+// when baseDir is neither in a discovered module nor a workspace root, so
+// that a package directory analyzed on its own keeps its import path.
+func (a *GoAnalyzer) addEnclosingModule() error {
+	if a.moduleForDir(a.baseDir) != nil {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(a.baseDir, "go.work")); err == nil {
+		return nil
+	}
+
+	for dir := filepath.Dir(a.baseDir); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			_, err := a.addModule(dir)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadGoWork adds the modules of the use directives in baseDir/go.work.
+func (a *GoAnalyzer) loadGoWork() error {
+	tracer.Enter("analyzer.GoAnalyzer.loadGoWork")
+
+	goWorkPath := filepath.Join(a.baseDir, "go.work")
+	data, err := os.ReadFile(goWorkPath)
+	if err != nil {
+		tracer.ExitSuccess("analyzer.GoAnalyzer.loadGoWork")
+		return nil
+	}
+
+	dirs, err := parseGoWork(goWorkPath, data)
+	if err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.loadGoWork", err)
+		return err
+	}
+
+	for _, dir := range dirs {
+		mod, err := a.addModule(dir)
+		if err != nil {
+			tracer.ExitError("analyzer.GoAnalyzer.loadGoWork", err)
+			return err
+		}
+		if mod == nil {
+			continue
+		}
+		mod.Workspace = true
+
+		if !isWithinDir(a.baseDir, dir) {
+			a.roots = append(a.roots, dir)
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.loadGoWork")
+	return nil
+}
+
+// parseGoWork returns the module directories of the use directives in a
+// go.work file.
+func parseGoWork(goWorkPath string, data []byte) ([]string, error) {
+	file, err := modfile.ParseWork(goWorkPath, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", goWorkPath, err)
+	}
+
+	dirs := make([]string, 0, len(file.Use))
+	for _, use := range file.Use {
+		dir := filepath.Clean(use.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(goWorkPath), dir)
+		}
+		dirs = append(dirs, dir)
+	}
+
+	return dirs, nil
+}
+
+// goWorkEnv returns the environment for running the go command in the
+// module in dir. A GOWORK set by the user is kept. Otherwise the go
+// command picks up the nearest go.work above dir: modules listed there
+// run in workspace mode, which rejects -mod flags, and modules it does
+// not list need GOWORK=off to load at all.
+func goWorkEnv(dir string, environ []string) []string {
+	for _, kv := range environ {
+		if goWork, ok := strings.CutPrefix(kv, "GOWORK="); ok && goWork != "" {
+			if goWork == "off" {
+				return environ
+			}
+			return workspaceEnviron(environ)
+		}
+	}
+
+	for current := dir; ; current = filepath.Dir(current) {
+		goWorkPath := filepath.Join(current, "go.work")
+		if data, err := os.ReadFile(goWorkPath); err == nil {
+			dirs, err := parseGoWork(goWorkPath, data)
+			if err != nil {
+				return append(environ, "GOWORK=off")
+			}
+			for _, use := range dirs {
+				if use == dir {
+					return workspaceEnviron(environ)
+				}
+			}
+			return append(environ, "GOWORK=off")
+		}
+
+		if filepath.Dir(current) == current {
+			return environ
+		}
+	}
+}
+
+// workspaceEnviron drops -mod flags from GOFLAGS, which the go command
+// rejects in workspace mode.
+func workspaceEnviron(environ []string) []string {
+	result := make([]string, 0, len(environ))
+
+	for _, kv := range environ {
+		flags, ok := strings.CutPrefix(kv, "GOFLAGS=")
+		if !ok {
+			result = append(result, kv)
+			continue
+		}
+
+		var kept []string
+		for _, flag := range strings.Fields(flags) {
+			if !strings.HasPrefix(flag, "-mod=") {
+				kept = append(kept, flag)
+			}
+		}
+		result = append(result, "GOFLAGS="+strings.Join(kept, " "))
+	}
+
+	return result
+}
+
+// addModule registers the module whose go.mod is in dir.
+func (a *GoAnalyzer) addModule(dir string) (*ModuleInfo, error) {
+	for _, mod := range a.modules {
+		if mod.Dir == dir {
+			return mod, nil
+		}
+	}
+
+	mod, err := a.loadGoMod(dir)
+	if err != nil || mod == nil || mod.Path == "" {
+		return nil, err
+	}
+
+	a.modules = append(a.modules, mod)
+
+	// Keep the deepest directories first for moduleForDir.
+	sort.Slice(a.modules, func(i, j int) bool {
+		return len(a.modules[i].Dir) > len(a.modules[j].Dir)
+	})
+
+	return mod, nil
+}
+
+// moduleForDir returns the module owning the package in dir, or nil when
+// dir is outside every module.
+func (a *GoAnalyzer) moduleForDir(dir string) *ModuleInfo {
+	for _, mod := range a.modules {
+		if isWithinDir(mod.Dir, dir) {
+			return mod
+		}
+	}
+
+	return nil
+}
+
+// moduleForImport returns the analyzed module providing importPath,
+// choosing the longest matching module path.
+func (a *GoAnalyzer) moduleForImport(importPath string) *ModuleInfo {
+	var best *ModuleInfo
+
+	for _, mod := range a.modules {
+		if importPath != mod.Path && !strings.HasPrefix(importPath, mod.Path+"/") {
+			continue
+		}
+		if best == nil || len(mod.Path) > len(best.Path) {
+			best = mod
+		}
+	}
+
+	return best
+}
+
+// packagePath returns the import path of the package in dir. Directories
+// outside every module are named relative to baseDir.
+func (a *GoAnalyzer) packagePath(dir string) (string, *ModuleInfo) {
+	base, root := a.modulePath, a.baseDir
+
+	mod := a.moduleForDir(dir)
+	if mod != nil {
+		base, root = mod.Path, mod.Dir
+	}
+
+	relDir, _ := filepath.Rel(root, dir)
+	if relDir == "." || relDir == "" {
+		return base, mod
+	}
+
+	return base + "/" + filepath.ToSlash(relDir), mod
+}
+
+func isWithinDir(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return false
+	}
+
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// buildModuleNodes adds a component per analyzed module and links it to
+// the packages it contains.
+func (a *GoAnalyzer) buildModuleNodes() {
+	tracer.Enter("analyzer.GoAnalyzer.buildModuleNodes")
+
+	modules := make([]*ModuleInfo, len(a.modules))
+	copy(modules, a.modules)
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Path < modules[j].Path
+	})
+
+	for _, mod := range modules {
+		a.nodes = append(a.nodes, model.Node{
+			ID:     moduleNodeID(mod.Path),
+			Title:  mod.Path,
+			Entity: "module",
+		})
+	}
+
+	paths := make([]string, 0, len(a.packages))
+	for path := range a.packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		pkg := a.packages[path]
+		if pkg.Module == "" {
+			continue
+		}
+
+		a.edges = append(a.edges, model.Edge{
+			From: moduleNodeID(pkg.Module),
+			To:   path,
+			Type: "contains",
+		})
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildModuleNodes")
+}
@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"runtime"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// fileTask is a source file selected for parsing by the directory walk.
+type fileTask struct {
+	path      string
+	platforms []string
+}
+
+// fileResult holds the declarations extracted from a single file. Workers
+// fill their own results, which are merged into the analyzer afterwards.
+type fileResult struct {
+	pkg       *PackageInfo // nil when the package is registered elsewhere
+	types     map[string]*TypeInfo
+	functions map[string]*FunctionInfo
+	methods   map[string]*MethodInfo
+	vars      map[string]*VarInfo
+}
+
+func newFileResult() *fileResult {
+	return &fileResult{
+		types:     make(map[string]*TypeInfo),
+		functions: make(map[string]*FunctionInfo),
+		methods:   make(map[string]*MethodInfo),
+		vars:      make(map[string]*VarInfo),
+	}
+}
+
+// parseFiles parses the walked files on a bounded worker pool and merges
+// the results in walk order, so the outcome does not depend on scheduling.
+func (a *GoAnalyzer) parseFiles() error {
+	tracer.Enter("analyzer.GoAnalyzer.parseFiles")
+
+	jobs := a.opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	// The tracer records a single call stack, so traced runs parse the
+	// files one at a time to keep the enter and exit events nested.
+	if tracer.Active() {
+		jobs = 1
+	}
+
+	results := make([]*fileResult, len(a.files))
+
+	var g errgroup.Group
+	g.SetLimit(jobs)
+
+	for i, task := range a.files {
+		g.Go(func() error {
+			result, err := a.parseFileCached(task)
+			if err != nil {
+				return err
+			}
+			results[i] = result
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		tracer.ExitError("analyzer.GoAnalyzer.parseFiles", err)
+		return err
+	}
+
+	for _, result := range results {
+		a.mergeResult(result)
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.parseFiles")
+	return nil
+}
+
+// mergeResult adds the declarations of a parsed file to the analyzer.
+func (a *GoAnalyzer) mergeResult(result *fileResult) {
+	tracer.Enter("analyzer.GoAnalyzer.mergeResult")
+
+	if result.pkg != nil {
+		pkg, exists := a.packages[result.pkg.Path]
+		if !exists {
+			pkg = &PackageInfo{
+				Name:    result.pkg.Name,
+				Path:    result.pkg.Path,
+				Dir:     result.pkg.Dir,
+				Module:  result.pkg.Module,
+				Imports: []string{},
+			}
+			a.packages[pkg.Path] = pkg
+		}
+
+		if pkg.Doc == "" {
+			pkg.Doc = result.pkg.Doc
+		}
+		pkg.Imports = append(pkg.Imports, result.pkg.Imports...)
+		pkg.DotImports = append(pkg.DotImports, result.pkg.DotImports...)
+		mergeImportUses(pkg, result.pkg.Uses)
+		pkg.Platforms = mergePlatforms(pkg.Platforms, result.pkg.Platforms)
+	}
+
+	for id, typeInfo := range result.types {
+		addType(a.types, id, typeInfo)
+	}
+
+	for id, funcInfo := range result.functions {
+		addFunction(a.functions, id, funcInfo)
+	}
+
+	for id, methodInfo := range result.methods {
+		addMethod(a.methods, id, methodInfo)
+	}
+
+	for id, varInfo := range result.vars {
+		addVar(a.vars, id, varInfo)
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.mergeResult")
+}
@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	"go/ast"
+	"sort"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// collectFuncRefs returns the functions and methods used as values within
+// node: call arguments, composite literal elements, assigned values and
+// variable initializers, e.g. filepath.Walk(dir, a.walkFunc) or
+// cobra.Command{RunE: runCollect}. When node is an expression it is
+// checked itself as well. Identifiers in locals are never references.
+func (a *GoAnalyzer) collectFuncRefs(node ast.Node, locals map[string]bool, fc *fileContext, scope *localScope) []CallInfo {
+	tracer.Enter("analyzer.GoAnalyzer.collectFuncRefs")
+
+	var refs []CallInfo
+
+	add := func(expr ast.Expr) {
+		for {
+			paren, ok := expr.(*ast.ParenExpr)
+			if !ok {
+				break
+			}
+			expr = paren.X
+		}
+
+		switch e := unwrapIndex(expr).(type) {
+		case *ast.Ident:
+			if locals[e.Name] {
+				return
+			}
+		case *ast.SelectorExpr:
+		default:
+			return
+		}
+
+		if ref, ok := a.callTarget(expr, fc, scope); ok {
+			ref.Line = fc.fset.Position(expr.Pos()).Line
+			refs = append(refs, ref)
+		}
+	}
+
+	if expr, ok := node.(ast.Expr); ok {
+		add(expr)
+	}
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch e := n.(type) {
+		case *ast.CallExpr:
+			for _, arg := range e.Args {
+				add(arg)
+			}
+		case *ast.CompositeLit:
+			for _, elt := range e.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					add(kv.Value)
+					continue
+				}
+				add(elt)
+			}
+		case *ast.AssignStmt:
+			for _, rhs := range e.Rhs {
+				add(rhs)
+			}
+		case *ast.ValueSpec:
+			for _, value := range e.Values {
+				add(value)
+			}
+		}
+		return true
+	})
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.collectFuncRefs")
+	return refs
+}
+
+// packageScope returns the scope of package-level initializers, which
+// declare no local names.
+func (a *GoAnalyzer) packageScope(fc *fileContext) *localScope {
+	return &localScope{
+		vars: make(map[string]TypeRef),
+		fc:   fc,
+		a:    a,
+	}
+}
+
+// buildReferenceEdges links functions, methods and variables to the
+// functions and methods they use as values.
+func (a *GoAnalyzer) buildReferenceEdges() {
+	tracer.Enter("analyzer.GoAnalyzer.buildReferenceEdges")
+
+	type user struct {
+		id   string
+		pkg  string
+		refs []CallInfo
+	}
+
+	var users []user
+	for id, funcInfo := range a.functions {
+		users = append(users, user{id, funcInfo.Package, funcInfo.Refs})
+	}
+	for id, methodInfo := range a.methods {
+		users = append(users, user{id, methodInfo.Package, methodInfo.Refs})
+	}
+	for id, varInfo := range a.vars {
+		users = append(users, user{id, varInfo.Package, varInfo.Refs})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].id < users[j].id
+	})
+
+	for _, u := range users {
+		seen := make(map[string]bool)
+
+		for _, ref := range u.refs {
+			target := a.resolveCallTarget(ref, u.pkg)
+			if target == "" || seen[target] {
+				continue
+			}
+			seen[target] = true
+
+			a.edges = append(a.edges, model.Edge{
+				From: u.id,
+				To:   target,
+				Type: "references",
+			})
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildReferenceEdges")
+}
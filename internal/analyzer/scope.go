@@ -0,0 +1,331 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// TypeRef describes the static type of an expression as far as it can be
+// inferred from syntax alone. It is resolved against the collected types
+// after every file has been parsed, see GoAnalyzer.resolveTypeRef.
+type TypeRef struct {
+	Name   string   // type name, empty when the type comes from Call
+	Pkg    string   // package of the type
+	Call   string   // function ID whose first result gives the type
+	Fields []string // field selectors applied to the base type
+}
+
+// localScope maps identifiers declared in a function (receiver, parameters
+// and local variables) to their inferred types. It is flow-insensitive:
+// a name keeps the first type it was seen with.
+type localScope struct {
+	vars map[string]TypeRef
+	fc   *fileContext
+	a    *GoAnalyzer
+}
+
+func (a *GoAnalyzer) newLocalScope(decl *ast.FuncDecl, fc *fileContext) *localScope {
+	tracer.Enter("analyzer.GoAnalyzer.newLocalScope")
+
+	s := &localScope{
+		vars: make(map[string]TypeRef),
+		fc:   fc,
+		a:    a,
+	}
+
+	if decl.Recv != nil {
+		for _, field := range decl.Recv.List {
+			receiver := a.getReceiverName(field.Type)
+			for _, name := range field.Names {
+				s.declare(name.Name, TypeRef{Name: receiver, Pkg: fc.pkgPath})
+			}
+		}
+	}
+
+	if decl.Type.Params != nil {
+		s.declareFields(decl.Type.Params)
+	}
+
+	if decl.Type.Results != nil {
+		s.declareFields(decl.Type.Results)
+	}
+
+	if decl.Body != nil {
+		ast.Inspect(decl.Body, s.visit)
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.newLocalScope")
+	return s
+}
+
+func (s *localScope) declare(name string, ref TypeRef) {
+	if name == "_" || name == "" {
+		return
+	}
+
+	if _, exists := s.vars[name]; !exists {
+		s.vars[name] = ref
+	}
+}
+
+func (s *localScope) declareFields(fields *ast.FieldList) {
+	for _, field := range fields.List {
+		typeName, typePkg := s.a.resolveTypeName(field.Type, s.fc)
+		if typeName == "" {
+			continue
+		}
+
+		for _, name := range field.Names {
+			s.declare(name.Name, TypeRef{Name: typeName, Pkg: typePkg})
+		}
+	}
+}
+
+func (s *localScope) visit(n ast.Node) bool {
+	switch stmt := n.(type) {
+	case *ast.AssignStmt:
+		s.visitAssign(stmt)
+	case *ast.ValueSpec:
+		s.visitValueSpec(stmt)
+	case *ast.FuncLit:
+		if stmt.Type.Params != nil {
+			s.declareFields(stmt.Type.Params)
+		}
+	}
+
+	return true
+}
+
+func (s *localScope) visitAssign(stmt *ast.AssignStmt) {
+	if stmt.Tok != token.DEFINE && stmt.Tok != token.ASSIGN {
+		return
+	}
+
+	if len(stmt.Lhs) == len(stmt.Rhs) {
+		for i, lhs := range stmt.Lhs {
+			if ident, ok := lhs.(*ast.Ident); ok {
+				if ref := s.valueType(stmt.Rhs[i]); ref != nil {
+					s.declare(ident.Name, *ref)
+				}
+			}
+		}
+		return
+	}
+
+	// x, err := f() takes the type of the first result.
+	if len(stmt.Rhs) == 1 && len(stmt.Lhs) > 1 {
+		if ident, ok := stmt.Lhs[0].(*ast.Ident); ok {
+			if ref := s.valueType(stmt.Rhs[0]); ref != nil {
+				s.declare(ident.Name, *ref)
+			}
+		}
+	}
+}
+
+func (s *localScope) visitValueSpec(spec *ast.ValueSpec) {
+	if spec.Type != nil {
+		typeName, typePkg := s.a.resolveTypeName(spec.Type, s.fc)
+		if typeName == "" {
+			return
+		}
+
+		for _, name := range spec.Names {
+			s.declare(name.Name, TypeRef{Name: typeName, Pkg: typePkg})
+		}
+		return
+	}
+
+	for i, name := range spec.Names {
+		if i < len(spec.Values) {
+			if ref := s.valueType(spec.Values[i]); ref != nil {
+				s.declare(name.Name, *ref)
+			}
+		}
+	}
+}
+
+// valueType infers the type of an expression used as an initializer.
+func (s *localScope) valueType(expr ast.Expr) *TypeRef {
+	switch e := expr.(type) {
+	case *ast.CompositeLit:
+		return s.typeExprRef(e.Type)
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return s.valueType(e.X)
+		}
+	case *ast.TypeAssertExpr:
+		return s.typeExprRef(e.Type)
+	case *ast.CallExpr:
+		return s.callResultType(e)
+	}
+
+	return s.typeOf(expr)
+}
+
+func (s *localScope) callResultType(call *ast.CallExpr) *TypeRef {
+	switch fun := unwrapIndex(call.Fun).(type) {
+	case *ast.Ident:
+		if fun.Name == "new" && len(call.Args) == 1 {
+			return s.typeExprRef(call.Args[0])
+		}
+		if _, local := s.vars[fun.Name]; !local && !s.a.isBuiltin(fun.Name) {
+			return &TypeRef{Call: s.fc.pkgPath + "." + fun.Name}
+		}
+	case *ast.SelectorExpr:
+		if ident, ok := fun.X.(*ast.Ident); ok {
+			if impPath, ok := s.packageRef(ident.Name); ok {
+				return &TypeRef{Call: impPath + "." + fun.Sel.Name}
+			}
+		}
+	}
+
+	return nil
+}
+
+// packageRef returns the import path name refers to, unless a local
+// declaration shadows the import.
+func (s *localScope) packageRef(name string) (string, bool) {
+	if _, local := s.vars[name]; local {
+		return "", false
+	}
+
+	return s.fc.imports.resolve(name)
+}
+
+func (s *localScope) typeExprRef(expr ast.Expr) *TypeRef {
+	if expr == nil {
+		return nil
+	}
+
+	typeName, typePkg := s.a.resolveTypeName(expr, s.fc)
+	if typeName == "" {
+		return nil
+	}
+
+	return &TypeRef{Name: typeName, Pkg: typePkg}
+}
+
+// typeOf infers the type of an operand expression: a known identifier
+// followed by any number of field selectors or index operations.
+func (s *localScope) typeOf(expr ast.Expr) *TypeRef {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		ref, ok := s.vars[e.Name]
+		if !ok {
+			return nil
+		}
+		return &ref
+	case *ast.SelectorExpr:
+		base := s.typeOf(e.X)
+		if base == nil {
+			return nil
+		}
+		fields := make([]string, 0, len(base.Fields)+1)
+		fields = append(fields, base.Fields...)
+		base.Fields = append(fields, e.Sel.Name)
+		return base
+	case *ast.ParenExpr:
+		return s.typeOf(e.X)
+	case *ast.StarExpr:
+		return s.typeOf(e.X)
+	case *ast.IndexExpr:
+		return s.typeOf(e.X)
+	case *ast.CallExpr:
+		return s.callResultType(e)
+	}
+
+	return nil
+}
+
+// resolveTypeRef resolves ref to the ID of a collected type, or "" when
+// some step of the selector chain cannot be followed.
+func (a *GoAnalyzer) resolveTypeRef(ref TypeRef) string {
+	tracer.Enter("analyzer.GoAnalyzer.resolveTypeRef")
+
+	typeID := a.lookupTypeID(ref.Pkg, ref.Name)
+	if ref.Call != "" {
+		typeID = a.resultTypeID(ref.Call)
+	}
+
+	for _, field := range ref.Fields {
+		if typeID == "" {
+			break
+		}
+		typeID = a.fieldTypeID(typeID, field, map[string]bool{})
+	}
+
+	if _, exists := a.types[typeID]; !exists {
+		tracer.ExitSuccess("analyzer.GoAnalyzer.resolveTypeRef")
+		return ""
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.resolveTypeRef")
+	return typeID
+}
+
+func (a *GoAnalyzer) resultTypeID(funcID string) string {
+	funcInfo, exists := a.functions[funcID]
+	if !exists || len(funcInfo.Results) == 0 {
+		return ""
+	}
+
+	result := funcInfo.Results[0]
+	if result.TypePkg == "" {
+		return ""
+	}
+
+	return result.TypePkg + "." + result.TypeName
+}
+
+// fieldTypeID returns the type ID of field name of typeID, following
+// embedded types for promoted fields.
+func (a *GoAnalyzer) fieldTypeID(typeID, name string, visited map[string]bool) string {
+	typeInfo, exists := a.types[typeID]
+	if !exists || visited[typeID] {
+		return ""
+	}
+	visited[typeID] = true
+
+	for _, field := range typeInfo.Fields {
+		if field.Name == name && field.TypePkg != "" {
+			return field.TypePkg + "." + field.TypeName
+		}
+	}
+
+	for _, embed := range typeInfo.Embeds {
+		embedID := embed.TypePkg + "." + embed.TypeName
+		if embed.Name == name {
+			return embedID
+		}
+		if id := a.fieldTypeID(embedID, name, visited); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// findMethod returns the ID of method name on typeID, following embedded
+// types for promoted methods.
+func (a *GoAnalyzer) findMethod(typeID, name string, visited map[string]bool) string {
+	methodID := typeID + "." + name
+	if _, exists := a.methods[methodID]; exists {
+		return methodID
+	}
+
+	typeInfo, exists := a.types[typeID]
+	if !exists || visited[typeID] {
+		return ""
+	}
+	visited[typeID] = true
+
+	for _, embed := range typeInfo.Embeds {
+		if id := a.findMethod(embed.TypePkg+"."+embed.TypeName, name, visited); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
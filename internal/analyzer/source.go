@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"bytes"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// docSummary returns the first sentence of the first non-empty comment
+// group, the way go doc shows it in package listings.
+func docSummary(groups ...*ast.CommentGroup) string {
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		if summary := new(doc.Package).Synopsis(group.Text()); summary != "" {
+			return summary
+		}
+	}
+
+	return ""
+}
+
+// declarationString renders the declaration of a function or method
+// without its body, e.g. "func (s *Service) Rename(id, name string) error".
+func declarationString(fset *token.FileSet, decl *ast.FuncDecl) string {
+	var buf bytes.Buffer
+
+	header := &ast.FuncDecl{Recv: decl.Recv, Name: decl.Name, Type: decl.Type}
+	if err := printer.Fprint(&buf, fset, header); err != nil {
+		return ""
+	}
+
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// interfaceMethodString renders an interface method, e.g.
+// "Get(id string) (*User, error)".
+func interfaceMethodString(fset *token.FileSet, name string, funcType *ast.FuncType) string {
+	var buf bytes.Buffer
+
+	if err := printer.Fprint(&buf, fset, funcType); err != nil {
+		return ""
+	}
+
+	return name + strings.TrimPrefix(strings.Join(strings.Fields(buf.String()), " "), "func")
+}
+
+// sourceFile returns filename relative to the root of its module, or to
+// the analyzed directory for files outside every module.
+func (a *GoAnalyzer) sourceFile(filename string) string {
+	root := a.baseDir
+	if mod := a.moduleForDir(filepath.Dir(filename)); mod != nil {
+		root = mod.Dir
+	}
+
+	rel, err := filepath.Rel(root, filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// exportedFlag returns the exported attribute of a declared name.
+func exportedFlag(name string) *bool {
+	exported := ast.IsExported(name)
+	return &exported
+}
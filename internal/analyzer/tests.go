@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// Test function kinds recognized by the go test tool.
+const (
+	TestKindTest      = "test"
+	TestKindBenchmark = "benchmark"
+	TestKindFuzz      = "fuzz"
+	TestKindExample   = "example"
+)
+
+var testPrefixes = []struct {
+	prefix string
+	kind   string
+}{
+	{"Test", TestKindTest},
+	{"Benchmark", TestKindBenchmark},
+	{"Fuzz", TestKindFuzz},
+	{"Example", TestKindExample},
+}
+
+func isTestFile(filename string) bool {
+	return strings.HasSuffix(filename, "_test.go")
+}
+
+// testKind returns the kind of a top-level function declared in a test
+// file, or "" for helpers. It follows the naming rules of go test: the
+// prefix is either the whole name or followed by a non-lowercase rune.
+func testKind(name string) string {
+	for _, tp := range testPrefixes {
+		if !strings.HasPrefix(name, tp.prefix) {
+			continue
+		}
+
+		rest := name[len(tp.prefix):]
+		if rest == "" {
+			return tp.kind
+		}
+
+		r, _ := utf8.DecodeRuneInString(rest)
+		if !unicode.IsLower(r) {
+			return tp.kind
+		}
+	}
+
+	return ""
+}
+
+// buildTestEdges links every test to the production functions and methods
+// it exercises, directly or through helpers declared in test files.
+func (a *GoAnalyzer) buildTestEdges() {
+	tracer.Enter("analyzer.GoAnalyzer.buildTestEdges")
+
+	ids := make([]string, 0, len(a.functions))
+	for id, funcInfo := range a.functions {
+		if funcInfo.TestKind != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		funcInfo := a.functions[id]
+		targets := make(map[string]bool)
+		a.collectTestTargets(funcInfo.Calls, funcInfo.Package, targets, map[string]bool{id: true})
+
+		sorted := make([]string, 0, len(targets))
+		for target := range targets {
+			sorted = append(sorted, target)
+		}
+		sort.Strings(sorted)
+
+		for _, target := range sorted {
+			a.edges = append(a.edges, model.Edge{
+				From: id,
+				To:   target,
+				Type: "tests",
+			})
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.buildTestEdges")
+}
+
+func (a *GoAnalyzer) collectTestTargets(calls []CallInfo, currentPkg string, targets, visited map[string]bool) {
+	for _, call := range calls {
+		target := a.resolveCallTarget(call, currentPkg)
+		if target == "" || visited[target] {
+			continue
+		}
+		visited[target] = true
+
+		if funcInfo, exists := a.functions[target]; exists {
+			if funcInfo.InTestFile {
+				a.collectTestTargets(funcInfo.Calls, funcInfo.Package, targets, visited)
+				continue
+			}
+		}
+
+		if methodInfo, exists := a.methods[target]; exists {
+			if methodInfo.InTestFile {
+				a.collectTestTargets(methodInfo.Calls, methodInfo.Package, targets, visited)
+				continue
+			}
+		}
+
+		targets[target] = true
+	}
+}
@@ -0,0 +1,289 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+var errPackageLoad = errors.New("failed to load packages")
+
+// typedLoadMode is the go/packages load mode used by type-checked analysis.
+const typedLoadMode = packages.NeedName | packages.NeedFiles | packages.NeedImports |
+	packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedModule
+
+// loadTyped loads all packages under baseDir with go/packages and extracts
+// declarations from the type-checked syntax trees. Every module is loaded
+// from its own directory. In union mode the packages are loaded once per
+// platform and the results merged.
+func (a *GoAnalyzer) loadTyped() error {
+	tracer.Enter("analyzer.GoAnalyzer.loadTyped")
+
+	// SSA construction needs dependencies loaded from source; otherwise
+	// their types come from export data.
+	mode := typedLoadMode
+	if a.usesSSA() {
+		mode |= packages.NeedDeps
+	}
+
+	for _, bc := range a.contexts {
+		var platforms []string
+		if bc.name != "" {
+			platforms = []string{bc.name}
+		}
+
+		for _, dir := range a.typedLoadDirs() {
+			env, buildFlags := typedBuildEnv(bc)
+
+			modDir := dir
+			if mod := a.moduleForDir(dir); mod != nil {
+				modDir = mod.Dir
+			}
+
+			cfg := &packages.Config{
+				Mode:       mode,
+				Dir:        dir,
+				Tests:      a.opts.IncludeTests,
+				Env:        append(goWorkEnv(modDir, os.Environ()), env...),
+				BuildFlags: buildFlags,
+			}
+
+			pkgs, err := packages.Load(cfg, "./...")
+			if err != nil {
+				tracer.ExitError("analyzer.GoAnalyzer.loadTyped", err)
+				return fmt.Errorf("%w: %v", errPackageLoad, err)
+			}
+
+			if err := firstLoadError(pkgs); err != nil {
+				tracer.ExitError("analyzer.GoAnalyzer.loadTyped", err)
+				return fmt.Errorf("%w: %v", errPackageLoad, err)
+			}
+
+			if a.usesSSA() {
+				a.addCallGraph(pkgs)
+			}
+
+			for _, pkg := range pkgs {
+				// Skip packages without sources, the generated test mains
+				// and packages in directories syntactic mode does not walk.
+				if len(pkg.Syntax) == 0 || strings.HasSuffix(pkg.ID, ".test") ||
+					skipPath(dir, filepath.Dir(pkg.GoFiles[0])) {
+					continue
+				}
+				a.parseTypedPackage(pkg, platforms)
+			}
+
+			a.addTypedImplements(pkgs)
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.loadTyped")
+	return nil
+}
+
+// typedLoadDirs returns the directories packages are loaded from: one per
+// module in path order, or baseDir when no module was found.
+func (a *GoAnalyzer) typedLoadDirs() []string {
+	if len(a.modules) == 0 {
+		return []string{a.baseDir}
+	}
+
+	modules := make([]*ModuleInfo, len(a.modules))
+	copy(modules, a.modules)
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Path < modules[j].Path
+	})
+
+	dirs := make([]string, 0, len(modules))
+	for _, mod := range modules {
+		// A module enclosing baseDir is only loaded below baseDir.
+		if isWithinDir(mod.Dir, a.baseDir) {
+			dirs = append(dirs, a.baseDir)
+			continue
+		}
+		dirs = append(dirs, mod.Dir)
+	}
+
+	return dirs
+}
+
+// firstLoadError returns the first listing or parse error among the loaded
+// packages. Type errors are tolerated: go/types still records every object
+// it managed to resolve.
+func firstLoadError(pkgs []*packages.Package) error {
+	tracer.Enter("analyzer.firstLoadError")
+
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			if pkgErr.Kind != packages.TypeError {
+				tracer.ExitError("analyzer.firstLoadError", pkgErr)
+				return pkgErr
+			}
+		}
+	}
+
+	tracer.ExitSuccess("analyzer.firstLoadError")
+	return nil
+}
+
+func (a *GoAnalyzer) parseTypedPackage(pkg *packages.Package, platforms []string) {
+	tracer.Enter("analyzer.GoAnalyzer.parseTypedPackage")
+
+	pkgInfo, exists := a.packages[pkg.PkgPath]
+	if !exists {
+		pkgInfo = &PackageInfo{
+			Name:    pkg.Name,
+			Path:    pkg.PkgPath,
+			Imports: []string{},
+		}
+		if pkg.Module != nil {
+			pkgInfo.Module = pkg.Module.Path
+		}
+		if len(pkg.GoFiles) > 0 {
+			pkgInfo.Dir = filepath.Dir(pkg.GoFiles[0])
+		}
+		a.packages[pkg.PkgPath] = pkgInfo
+	}
+	pkgInfo.Platforms = mergePlatforms(pkgInfo.Platforms, platforms)
+
+	for impPath := range pkg.Imports {
+		if !a.isStdLib(impPath) {
+			pkgInfo.Imports = append(pkgInfo.Imports, impPath)
+		}
+	}
+
+	for _, file := range pkg.Syntax {
+		filename := pkg.Fset.Position(file.Pos()).Filename
+		result := newFileResult()
+
+		if pkgInfo.Doc == "" {
+			pkgInfo.Doc = docSummary(file.Doc)
+		}
+
+		imports := newImportTable(file)
+		mergeImportUses(pkgInfo, collectImportUses(file, imports, pkg.TypesInfo))
+
+		a.parseDecls(file, &fileContext{
+			fset:      pkg.Fset,
+			pkgPath:   pkg.PkgPath,
+			filename:  filename,
+			platforms: platforms,
+			imports:   imports,
+			info:      pkg.TypesInfo,
+			result:    result,
+		})
+
+		a.mergeResult(result)
+	}
+
+	tracer.ExitSuccess("analyzer.GoAnalyzer.parseTypedPackage")
+}
+
+// calleeID returns the component ID of the function or method invoked
+// through fun, or "" when type information is unavailable.
+func calleeID(fun ast.Expr, info *types.Info) string {
+	tracer.Enter("analyzer.calleeID")
+
+	if info == nil {
+		tracer.ExitSuccess("analyzer.calleeID")
+		return ""
+	}
+
+	var obj types.Object
+
+	switch f := fun.(type) {
+	case *ast.Ident:
+		obj = info.Uses[f]
+	case *ast.SelectorExpr:
+		if sel, ok := info.Selections[f]; ok {
+			obj = sel.Obj()
+		} else {
+			obj = info.Uses[f.Sel]
+		}
+	}
+
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		tracer.ExitSuccess("analyzer.calleeID")
+		return ""
+	}
+
+	id := funcObjectID(fn)
+
+	tracer.ExitSuccess("analyzer.calleeID")
+	return id
+}
+
+// funcObjectID maps a function or method object to its component ID:
+// pkg.Func for functions and pkg.Type.Method for methods.
+func funcObjectID(fn *types.Func) string {
+	tracer.Enter("analyzer.funcObjectID")
+
+	fn = fn.Origin()
+	if fn.Pkg() == nil {
+		tracer.ExitSuccess("analyzer.funcObjectID")
+		return ""
+	}
+
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		tracer.ExitSuccess("analyzer.funcObjectID")
+		return fn.Pkg().Path() + "." + fn.Name()
+	}
+
+	recvName, _ := namedTypeOf(sig.Recv().Type())
+	if recvName == "" {
+		tracer.ExitSuccess("analyzer.funcObjectID")
+		return ""
+	}
+
+	tracer.ExitSuccess("analyzer.funcObjectID")
+	return fn.Pkg().Path() + "." + recvName + "." + fn.Name()
+}
+
+// namedTypeOf returns the name and package path of the named type behind t,
+// looking through pointers, slices, arrays, maps (value side) and channels,
+// the same way resolveTypeName walks type expressions.
+func namedTypeOf(t types.Type) (string, string) {
+	for {
+		switch tt := t.(type) {
+		case *types.Pointer:
+			t = tt.Elem()
+		case *types.Slice:
+			t = tt.Elem()
+		case *types.Array:
+			t = tt.Elem()
+		case *types.Map:
+			t = tt.Elem()
+		case *types.Chan:
+			t = tt.Elem()
+		case *types.Alias:
+			return typeNameObject(tt.Obj())
+		case *types.Named:
+			return typeNameObject(tt.Origin().Obj())
+		case *types.TypeParam:
+			return tt.Obj().Name(), ""
+		case *types.Basic:
+			return tt.Name(), ""
+		default:
+			return "", ""
+		}
+	}
+}
+
+func typeNameObject(obj *types.TypeName) (string, string) {
+	if obj.Pkg() == nil {
+		return obj.Name(), ""
+	}
+
+	return obj.Name(), obj.Pkg().Path()
+}
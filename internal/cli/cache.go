@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mshogin/archlint/internal/analyzer"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+var errNoCacheDir = errors.New("user cache directory unknown, use --cache-dir")
+
+var (
+	cachePruneDir       string
+	cachePruneOlderThan time.Duration
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the analysis cache",
+	Long: `Manages the per-file analysis cache written by collect.
+
+Cache entries are keyed by file content, analyzer version and build
+settings, so stale entries are never read. They are only left behind.`,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune [directory]",
+	Short: "Remove unused cache entries",
+	Long: `Removes cache entries that have not been used for longer than
+--older-than. With --older-than 0 the whole cache is cleared.
+
+Example:
+  archlint cache prune .
+  archlint cache prune . --older-than 0`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCachePrune,
+}
+
+func init() {
+	cachePruneCmd.Flags().StringVar(&cachePruneDir, "cache-dir", "",
+		"Analysis cache directory (default: <user cache dir>/archlint/<module>)")
+	cachePruneCmd.Flags().DurationVar(&cachePruneOlderThan, "older-than", 7*24*time.Hour,
+		"Remove entries not used for this long, 0 removes all entries")
+	cacheCmd.AddCommand(cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	tracer.Enter("cli.runCachePrune")
+
+	dir := cachePruneDir
+	if dir == "" {
+		codeDir := "."
+		if len(args) > 0 {
+			codeDir = args[0]
+		}
+		dir = defaultCacheDir(codeDir)
+	}
+	if dir == "" {
+		tracer.ExitError("cli.runCachePrune", errNoCacheDir)
+		return errNoCacheDir
+	}
+
+	removed, err := analyzer.PruneCache(dir, cachePruneOlderThan)
+	if err != nil {
+		tracer.ExitError("cli.runCachePrune", err)
+		return err
+	}
+
+	fmt.Printf("Removed %d cache entries from %s\n", removed, dir)
+
+	tracer.ExitSuccess("cli.runCachePrune")
+	return nil
+}
+
+// defaultCacheDir returns the cache directory used for the code in codeDir:
+// one directory per module root below the user cache directory, so the
+// analyzed tree is never written to. It returns "" when the user cache
+// directory is unknown, which disables caching.
+func defaultCacheDir(codeDir string) string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	root := moduleRoot(codeDir)
+	sum := sha256.Sum256([]byte(root))
+
+	return filepath.Join(base, "archlint", filepath.Base(root)+"-"+hex.EncodeToString(sum[:8]))
+}
+
+// moduleRoot returns the absolute directory of the go.mod governing codeDir,
+// or codeDir itself outside modules.
+func moduleRoot(codeDir string) string {
+	dir, err := filepath.Abs(codeDir)
+	if err != nil {
+		return codeDir
+	}
+
+	for current := dir; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(filepath.Join(current, "go.mod")); err == nil {
+			return current
+		}
+		if filepath.Dir(current) == current {
+			return dir
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -24,15 +25,66 @@ var (
 var (
 	collectOutputFile string
 	collectLanguage   string
+	collectTypeCheck  bool
+	collectExtPkgs    bool
+	collectTests      bool
+	collectGOOS       string
+	collectGOARCH     string
+	collectTags       []string
+	collectPlatforms  []string
+	collectJobs       int
+	collectCacheDir   string
+	collectNoCache    bool
+	collectConcurrent bool
+	collectCallGraph  string
+	collectImportInfo bool
 )
 
 var collectCmd = &cobra.Command{
 	Use:   "collect [directory]",
 	Short: "Collect architecture from source code",
 	Long: `Analyzes source code and builds an architecture graph in YAML format.
+The file starts with its schema version and metadata: the archlint
+version, the module path, the git revision, the generation time and the
+analysis options that differ from the defaults.
+
+With --typecheck the module is loaded through go/packages and every call,
+field type and embed is resolved to its declaring object, including objects
+in other packages of the module.
+
+Files are selected by build constraints for the host platform, or for
+--goos/--goarch/--tags. With --platforms the union over several platforms
+is collected and every component lists the platforms it exists on.
+
+Every go.mod below the directory and every module of its go.work file is
+analyzed under its own module path and represented by a module component.
+
+Per-file results are cached in a directory per module below the user
+cache directory (e.g. ~/.cache/archlint), so re-runs only parse files
+that changed and the analyzed tree is left untouched. Use --no-cache to
+parse everything and "archlint cache prune" to clean up old entries.
+
+With --callgraph=static|cha|rta|vta the module is compiled to SSA form
+and call edges come from the whole-program call graph algorithm of that
+name, which also resolves calls through interfaces and function values.
+Each such edge records the algorithm that found it. The default, syntax,
+collects calls from the source of each function.
+
+With --import-detail every import edge lists the symbols the importing
+package uses from the imported one and how often it uses them, telling a
+single helper apart from deep coupling.
+
+With --concurrency only the concurrent structure is written: goroutines
+started with go, deferred calls, channel fields and variables and the
+functions sending to or receiving from them.
 
 Example:
-  archlint collect . -l go -o architecture.yaml`,
+  archlint collect . -l go -o architecture.yaml
+  archlint collect . --typecheck
+  archlint collect . --platforms linux/amd64,windows/amd64
+  archlint collect . --callgraph=vta
+  archlint collect . --import-detail
+  archlint collect . --concurrency -o concurrency.yaml`,
 	Args: cobra.ExactArgs(1),
 	RunE: runCollect,
 }
@@ -42,6 +94,32 @@ func init() {
 		"architecture.yaml", "Output YAML file")
 	collectCmd.Flags().StringVarP(&collectLanguage, "language", "l",
 		"go", "Programming language (go)")
+	collectCmd.Flags().BoolVar(&collectTypeCheck, "typecheck", false,
+		"Resolve identifiers with go/packages and go/types")
+	collectCmd.Flags().BoolVar(&collectExtPkgs, "external-packages", false,
+		"Add a component per imported third-party package, not only per module")
+	collectCmd.Flags().BoolVar(&collectTests, "include-tests", false,
+		"Analyze _test.go files and link tests to the code they exercise")
+	collectCmd.Flags().StringVar(&collectGOOS, "goos", "",
+		"Target operating system for build constraints (default: host)")
+	collectCmd.Flags().StringVar(&collectGOARCH, "goarch", "",
+		"Target architecture for build constraints (default: host)")
+	collectCmd.Flags().StringSliceVar(&collectTags, "tags", nil,
+		"Build tags to satisfy, comma-separated")
+	collectCmd.Flags().StringSliceVar(&collectPlatforms, "platforms", nil,
+		"Collect the union over GOOS/GOARCH pairs, e.g. linux/amd64,windows/amd64")
+	collectCmd.Flags().IntVarP(&collectJobs, "jobs", "j", 0,
+		"Number of files parsed in parallel (default: number of CPUs)")
+	collectCmd.Flags().StringVar(&collectCacheDir, "cache-dir", "",
+		"Analysis cache directory (default: <user cache dir>/archlint/<module>)")
+	collectCmd.Flags().BoolVar(&collectNoCache, "no-cache", false,
+		"Parse every file without reading or writing the analysis cache")
+	collectCmd.Flags().StringVar(&collectCallGraph, "callgraph", analyzer.CallGraphSyntax,
+		"Call graph algorithm: "+strings.Join(analyzer.CallGraphAlgorithms, ", "))
+	collectCmd.Flags().BoolVar(&collectImportInfo, "import-detail", false,
+		"List the used symbols and their number of uses on import edges")
+	collectCmd.Flags().BoolVar(&collectConcurrent, "concurrency", false,
+		"Keep only goroutines, deferred calls and channel operations")
 	rootCmd.AddCommand(collectCmd)
 }
 
@@ -65,7 +143,7 @@ func runCollect(cmd *cobra.Command, args []string) error {
 
 	printStats(graph)
 
-	if err := saveGraph(graph); err != nil {
+	if err := saveGraph(graph, collectOutputFile); err != nil {
 		tracer.ExitError("cli.runCollect", err)
 		return err
 	}
@@ -84,13 +162,46 @@ func analyzeCode(codeDir string) (*model.Graph, error) {
 		return nil, fmt.Errorf("%w: %s", errUnsupportedLang, collectLanguage)
 	}
 
-	a := analyzer.NewGoAnalyzer()
+	cacheDir := ""
+	if !collectNoCache {
+		cacheDir = collectCacheDir
+		if cacheDir == "" {
+			cacheDir = defaultCacheDir(codeDir)
+		}
+	}
+
+	opts := analyzer.Options{
+		TypeCheck:        collectTypeCheck,
+		ExternalPackages: collectExtPkgs,
+		IncludeTests:     collectTests,
+		GOOS:             collectGOOS,
+		GOARCH:           collectGOARCH,
+		Tags:             collectTags,
+		Platforms:        collectPlatforms,
+		Jobs:             collectJobs,
+		CacheDir:         cacheDir,
+		CallGraph:        collectCallGraph,
+		ImportDetail:     collectImportInfo,
+	}
+	a := analyzer.NewGoAnalyzerWithOptions(opts)
 	graph, err := a.Analyze(codeDir)
 	if err != nil {
 		tracer.ExitError("cli.analyzeCode", err)
 		return nil, fmt.Errorf("analysis failed: %w", err)
 	}
 
+	metadata := newMetadata(codeDir, a, opts)
+
+	if collectConcurrent {
+		graph = analyzer.ConcurrencyView(graph)
+		if metadata.Options == nil {
+			metadata.Options = make(map[string]string)
+		}
+		metadata.Options["view"] = "concurrency"
+	}
+
+	graph.Metadata = metadata
+
 	tracer.ExitSuccess("cli.analyzeCode")
 	return graph, nil
 }
@@ -112,10 +223,19 @@ func printStats(graph *model.Graph) {
 	tracer.ExitSuccess("cli.printStats")
 }
 
-func saveGraph(graph *model.Graph) error {
+func saveGraph(graph *model.Graph, filename string) error {
 	tracer.Enter("cli.saveGraph")
 
-	file, err := os.Create(collectOutputFile)
+	graph.Schema = model.SchemaVersion
+
+	for _, node := range graph.Nodes {
+		if err := node.Validate(); err != nil {
+			tracer.ExitError("cli.saveGraph", err)
+			return err
+		}
+	}
+
+	file, err := os.Create(filename)
 	if err != nil {
 		tracer.ExitError("cli.saveGraph", err)
 		return fmt.Errorf("%w: %v", errFileCreate, err)
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mshogin/archlint/internal/analyzer"
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+var errConfigRead = errors.New("failed to read config")
+
+var (
+	deadcodeOutputFile string
+	deadcodeTypeCheck  bool
+	deadcodeCallGraph  string
+	deadcodeRoots      []string
+	deadcodeNoExported bool
+	deadcodeNoTests    bool
+	deadcodeTag        bool
+	deadcodeCacheDir   string
+	deadcodeNoCache    bool
+)
+
+var deadcodeCmd = &cobra.Command{
+	Use:   "deadcode [directory]",
+	Short: "Report functions, methods and types that are never reached",
+	Long: `Builds the architecture graph and reports every function, method and
+type that is not reachable from a root along calls, references and type
+dependencies.
+
+Roots are the main functions of main packages, init functions, package
+variables and constants, exported declarations of packages other
+modules can import (unless --no-exported), and tests (unless --no-tests).
+Additional roots, such as handlers registered through reflection, are
+given with --root or in the deadcode section of <directory>/.archlint.yaml:
+
+  deadcode:
+    roots:
+      - example.com/app/handlers.*
+
+With --tag the graph is written to --output with an "unreachable" tag on
+every reported component.
+
+Example:
+  archlint deadcode .
+  archlint deadcode . --callgraph=rta --root 'example.com/app/plugins.**'
+  archlint deadcode . --tag -o architecture.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDeadcode,
+}
+
+func init() {
+	deadcodeCmd.Flags().StringVarP(&deadcodeOutputFile, "output", "o",
+		"architecture.yaml", "Output YAML file for --tag")
+	deadcodeCmd.Flags().BoolVar(&deadcodeTypeCheck, "typecheck", false,
+		"Resolve identifiers with go/packages and go/types")
+	deadcodeCmd.Flags().StringVar(&deadcodeCallGraph, "callgraph", analyzer.CallGraphSyntax,
+		"Call graph algorithm: "+strings.Join(analyzer.CallGraphAlgorithms, ", "))
+	deadcodeCmd.Flags().StringSliceVar(&deadcodeRoots, "root", nil,
+		"Additional root component patterns, e.g. example.com/app/handlers.*")
+	deadcodeCmd.Flags().BoolVar(&deadcodeNoExported, "no-exported", false,
+		"Do not treat exported declarations as roots")
+	deadcodeCmd.Flags().BoolVar(&deadcodeNoTests, "no-tests", false,
+		"Do not analyze tests or treat them as roots")
+	deadcodeCmd.Flags().BoolVar(&deadcodeTag, "tag", false,
+		"Write the graph with unreachable components tagged to --output")
+	deadcodeCmd.Flags().StringVar(&deadcodeCacheDir, "cache-dir", "",
+		"Analysis cache directory (default: <user cache dir>/archlint/<module>)")
+	deadcodeCmd.Flags().BoolVar(&deadcodeNoCache, "no-cache", false,
+		"Parse every file without reading or writing the analysis cache")
+	rootCmd.AddCommand(deadcodeCmd)
+}
+
+// deadcodeConfig is the deadcode section of .archlint.yaml.
+type deadcodeConfig struct {
+	Deadcode struct {
+		Roots []string `yaml:"roots"`
+	} `yaml:"deadcode"`
+}
+
+func runDeadcode(cmd *cobra.Command, args []string) error {
+	tracer.Enter("cli.runDeadcode")
+
+	codeDir := args[0]
+
+	if _, err := os.Stat(codeDir); os.IsNotExist(err) {
+		tracer.ExitError("cli.runDeadcode", errDirNotExist)
+		return fmt.Errorf("%w: %s", errDirNotExist, codeDir)
+	}
+
+	roots, err := loadDeadcodeRoots(codeDir)
+	if err != nil {
+		tracer.ExitError("cli.runDeadcode", err)
+		return err
+	}
+
+	cacheDir := ""
+	if !deadcodeNoCache {
+		cacheDir = deadcodeCacheDir
+		if cacheDir == "" {
+			cacheDir = defaultCacheDir(codeDir)
+		}
+	}
+
+	opts := analyzer.Options{
+		TypeCheck:    deadcodeTypeCheck,
+		IncludeTests: !deadcodeNoTests,
+		CacheDir:     cacheDir,
+		CallGraph:    deadcodeCallGraph,
+		BodyTypes:    true,
+	}
+	a := analyzer.NewGoAnalyzerWithOptions(opts)
+	graph, err := a.Analyze(codeDir)
+	if err != nil {
+		tracer.ExitError("cli.runDeadcode", err)
+		return fmt.Errorf("analysis failed: %w", err)
+	}
+
+	dead := analyzer.FindDeadCode(graph, analyzer.DeadCodeOptions{
+		Exported: !deadcodeNoExported,
+		Tests:    !deadcodeNoTests,
+		Roots:    append(roots, deadcodeRoots...),
+	})
+
+	printDeadcode(dead)
+
+	if deadcodeTag {
+		analyzer.MarkUnreachable(graph, dead)
+		graph.Metadata = newMetadata(codeDir, a, opts)
+
+		if err := saveGraph(graph, deadcodeOutputFile); err != nil {
+			tracer.ExitError("cli.runDeadcode", err)
+			return err
+		}
+
+		fmt.Printf("Graph saved to %s\n", deadcodeOutputFile)
+	}
+
+	tracer.ExitSuccess("cli.runDeadcode")
+	return nil
+}
+
+// loadDeadcodeRoots returns the roots configured in codeDir/.archlint.yaml.
+func loadDeadcodeRoots(codeDir string) ([]string, error) {
+	tracer.Enter("cli.loadDeadcodeRoots")
+
+	data, err := os.ReadFile(filepath.Join(codeDir, ".archlint.yaml"))
+	if os.IsNotExist(err) {
+		tracer.ExitSuccess("cli.loadDeadcodeRoots")
+		return nil, nil
+	}
+	if err != nil {
+		tracer.ExitError("cli.loadDeadcodeRoots", err)
+		return nil, fmt.Errorf("%w: %v", errConfigRead, err)
+	}
+
+	var config deadcodeConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		tracer.ExitError("cli.loadDeadcodeRoots", err)
+		return nil, fmt.Errorf("%w: %v", errConfigRead, err)
+	}
+
+	tracer.ExitSuccess("cli.loadDeadcodeRoots")
+	return config.Deadcode.Roots, nil
+}
+
+func printDeadcode(dead []model.Node) {
+	tracer.Enter("cli.printDeadcode")
+
+	fmt.Printf("Unreachable components: %d\n", len(dead))
+	for _, node := range dead {
+		location := ""
+		if node.File != "" {
+			location = fmt.Sprintf("  (%s:%d)", node.File, node.Line)
+		}
+		fmt.Printf("  %-9s %s%s\n", node.Entity, node.ID, location)
+	}
+
+	tracer.ExitSuccess("cli.printDeadcode")
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+var errNewDependencies = errors.New("new cross-package dependencies")
+
+var (
+	diffFormat        string
+	diffFailOnNewDeps bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [old graph] [new graph]",
+	Short: "Compare two architecture graphs",
+	Long: `Compares two graph files in YAML or JSON format and reports added and
+removed components, components whose entity changed, added and removed
+links grouped by type, and new dependencies between packages.
+
+Components are matched by ID and links by type and endpoints, so the
+order of the files does not matter.
+
+With --fail-on-new-deps the command exits with a non-zero status when a
+package depends on another package or external module it did not depend
+on before, which suits merge request checks.
+
+Example:
+  archlint diff main.yaml architecture.yaml
+  archlint diff main.yaml architecture.yaml -f markdown > diff.md
+  archlint diff main.yaml architecture.yaml --fail-on-new-deps`,
+	Args:         cobra.ExactArgs(2),
+	RunE:         runDiff,
+	SilenceUsage: true,
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "f",
+		formatText, "Output format: text, markdown or json")
+	diffCmd.Flags().BoolVar(&diffFailOnNewDeps, "fail-on-new-deps", false,
+		"Exit with an error when new cross-package dependencies appear")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	tracer.Enter("cli.runDiff")
+
+	switch diffFormat {
+	case formatText, formatMarkdown, formatJSON:
+	default:
+		tracer.ExitError("cli.runDiff", errUnknownFormat)
+		return fmt.Errorf("%w: %s", errUnknownFormat, diffFormat)
+	}
+
+	old, err := model.LoadGraph(args[0])
+	if err != nil {
+		tracer.ExitError("cli.runDiff", err)
+		return err
+	}
+
+	updated, err := model.LoadGraph(args[1])
+	if err != nil {
+		tracer.ExitError("cli.runDiff", err)
+		return err
+	}
+
+	diff := model.Diff(old, updated)
+
+	switch diffFormat {
+	case formatJSON:
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			tracer.ExitError("cli.runDiff", err)
+			return fmt.Errorf("failed to serialize JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case formatMarkdown:
+		writeDiffMarkdown(os.Stdout, diff)
+	default:
+		writeDiffText(os.Stdout, diff)
+	}
+
+	if diffFailOnNewDeps && len(diff.NewDependencies) > 0 {
+		err := fmt.Errorf("%w: %d", errNewDependencies, len(diff.NewDependencies))
+		tracer.ExitError("cli.runDiff", err)
+		return err
+	}
+
+	tracer.ExitSuccess("cli.runDiff")
+	return nil
+}
+
+func writeDiffText(w io.Writer, diff *model.GraphDiff) {
+	tracer.Enter("cli.writeDiffText")
+
+	if diff.Empty() {
+		fmt.Fprintln(w, "No architecture changes")
+		tracer.ExitSuccess("cli.writeDiffText")
+		return
+	}
+
+	fmt.Fprintf(w, "Components: +%d -%d ~%d\n",
+		len(diff.AddedNodes), len(diff.RemovedNodes), len(diff.ChangedNodes))
+	for _, node := range diff.AddedNodes {
+		fmt.Fprintf(w, "  + %-9s %s\n", node.Entity, node.ID)
+	}
+	for _, node := range diff.RemovedNodes {
+		fmt.Fprintf(w, "  - %-9s %s\n", node.Entity, node.ID)
+	}
+	for _, change := range diff.ChangedNodes {
+		fmt.Fprintf(w, "  ~ %s: %s -> %s\n", change.ID, change.OldEntity, change.NewEntity)
+	}
+
+	fmt.Fprintf(w, "Links: +%d -%d\n", len(diff.AddedEdges), len(diff.RemovedEdges))
+	for _, edgeType := range diff.EdgeTypes() {
+		fmt.Fprintf(w, "  %s:\n", edgeType)
+		for _, edge := range edgesOfType(diff.AddedEdges, edgeType) {
+			fmt.Fprintf(w, "    + %s -> %s\n", edge.From, edge.To)
+		}
+		for _, edge := range edgesOfType(diff.RemovedEdges, edgeType) {
+			fmt.Fprintf(w, "    - %s -> %s\n", edge.From, edge.To)
+		}
+	}
+
+	fmt.Fprintf(w, "New package dependencies: %d\n", len(diff.NewDependencies))
+	for _, dep := range diff.NewDependencies {
+		fmt.Fprintf(w, "  %s -> %s\n", dep.From, dep.To)
+	}
+
+	tracer.ExitSuccess("cli.writeDiffText")
+}
+
+func writeDiffMarkdown(w io.Writer, diff *model.GraphDiff) {
+	tracer.Enter("cli.writeDiffMarkdown")
+
+	fmt.Fprintln(w, "## Architecture diff")
+	fmt.Fprintln(w)
+
+	if diff.Empty() {
+		fmt.Fprintln(w, "No architecture changes.")
+		tracer.ExitSuccess("cli.writeDiffMarkdown")
+		return
+	}
+
+	fmt.Fprintln(w, "| | Added | Removed | Changed |")
+	fmt.Fprintln(w, "|---|---:|---:|---:|")
+	fmt.Fprintf(w, "| Components | %d | %d | %d |\n",
+		len(diff.AddedNodes), len(diff.RemovedNodes), len(diff.ChangedNodes))
+	fmt.Fprintf(w, "| Links | %d | %d | |\n", len(diff.AddedEdges), len(diff.RemovedEdges))
+	fmt.Fprintf(w, "| New package dependencies | %d | | |\n", len(diff.NewDependencies))
+
+	if len(diff.NewDependencies) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "### New package dependencies")
+		fmt.Fprintln(w)
+		for _, dep := range diff.NewDependencies {
+			fmt.Fprintf(w, "- `%s` → `%s`\n", dep.From, dep.To)
+		}
+	}
+
+	if len(diff.AddedNodes)+len(diff.RemovedNodes)+len(diff.ChangedNodes) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "### Components")
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "| Change | Entity | ID |")
+		fmt.Fprintln(w, "|---|---|---|")
+		for _, node := range diff.AddedNodes {
+			fmt.Fprintf(w, "| added | %s | `%s` |\n", node.Entity, node.ID)
+		}
+		for _, node := range diff.RemovedNodes {
+			fmt.Fprintf(w, "| removed | %s | `%s` |\n", node.Entity, node.ID)
+		}
+		for _, change := range diff.ChangedNodes {
+			fmt.Fprintf(w, "| changed | %s → %s | `%s` |\n", change.OldEntity, change.NewEntity, change.ID)
+		}
+	}
+
+	for _, edgeType := range diff.EdgeTypes() {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "### Links: %s\n", edgeType)
+		fmt.Fprintln(w)
+		var lines []string
+		for _, edge := range edgesOfType(diff.AddedEdges, edgeType) {
+			lines = append(lines, fmt.Sprintf("- added `%s` → `%s`", edge.From, edge.To))
+		}
+		for _, edge := range edgesOfType(diff.RemovedEdges, edgeType) {
+			lines = append(lines, fmt.Sprintf("- removed `%s` → `%s`", edge.From, edge.To))
+		}
+		fmt.Fprintln(w, strings.Join(lines, "\n"))
+	}
+
+	tracer.ExitSuccess("cli.writeDiffMarkdown")
+}
+
+func edgesOfType(edges []model.Edge, edgeType string) []model.Edge {
+	var filtered []model.Edge
+	for _, edge := range edges {
+		if edge.Type == edgeType {
+			filtered = append(filtered, edge)
+		}
+	}
+
+	return filtered
+}
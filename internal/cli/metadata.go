@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mshogin/archlint/internal/analyzer"
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// newMetadata describes a graph collected from codeDir by a.
+func newMetadata(codeDir string, a *analyzer.GoAnalyzer, opts analyzer.Options) *model.Metadata {
+	tracer.Enter("cli.newMetadata")
+
+	revision, dirty := gitRevision(codeDir)
+
+	metadata := &model.Metadata{
+		ArchlintVersion: version,
+		Module:          a.ModulePath(),
+		Revision:        revision,
+		Dirty:           dirty,
+		GeneratedAt:     time.Now().UTC().Format(time.RFC3339),
+		Options:         analysisOptions(opts),
+	}
+	if modules := a.Modules(); len(modules) > 1 {
+		metadata.Modules = modules
+	}
+
+	tracer.ExitSuccess("cli.newMetadata")
+	return metadata
+}
+
+// analysisOptions lists the options that change the collected graph and
+// differ from their defaults. Jobs and the cache only affect speed.
+func analysisOptions(opts analyzer.Options) map[string]string {
+	options := make(map[string]string)
+
+	set := func(name string, value bool) {
+		if value {
+			options[name] = strconv.FormatBool(value)
+		}
+	}
+	setString := func(name, value string) {
+		if value != "" {
+			options[name] = value
+		}
+	}
+
+	set("typecheck", opts.TypeCheck)
+	set("external_packages", opts.ExternalPackages)
+	set("include_tests", opts.IncludeTests)
+	set("import_detail", opts.ImportDetail)
+	set("body_types", opts.BodyTypes)
+	setString("goos", opts.GOOS)
+	setString("goarch", opts.GOARCH)
+	setString("tags", strings.Join(opts.Tags, ","))
+	setString("platforms", strings.Join(opts.Platforms, ","))
+	if opts.CallGraph != analyzer.CallGraphSyntax {
+		setString("callgraph", opts.CallGraph)
+	}
+
+	if len(options) == 0 {
+		return nil
+	}
+
+	return options
+}
+
+// gitRevision returns the commit checked out in dir and whether the work
+// tree has uncommitted changes, or "" when dir is not in a git work tree.
+func gitRevision(dir string) (string, bool) {
+	tracer.Enter("cli.gitRevision")
+
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		tracer.ExitSuccess("cli.gitRevision")
+		return "", false
+	}
+	revision := strings.TrimSpace(string(out))
+
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	dirty := err == nil && len(strings.TrimSpace(string(status))) > 0
+
+	tracer.ExitSuccess("cli.gitRevision")
+	return revision, dirty
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+var errUnknownFormat = errors.New("unknown output format")
+
+// Output formats of query and diff.
+const (
+	formatTable    = "table"
+	formatText     = "text"
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+	formatYAML     = "yaml"
+)
+
+var (
+	queryGraphFile string
+	queryFormat    string
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query '<expr>'",
+	Short: "Query the architecture graph",
+	Long: `Answers questions about a collected graph with a small query language:
+
+  op(arg, ...) [depth N] [via type,...] [where entity=kind,...] [match pattern]
+
+Operations:
+  callers(C)   components calling C, transitively
+  callees(C)   components C calls, transitively
+  deps(C)      components C depends on along any edge but contains
+  rdeps(C)     components depending on C along any edge but contains
+  path(A, B)   a shortest path from A to B
+  nodes(P, ...) components matching the patterns
+
+Components are given by ID, by pattern (pkg.*, pkg.**) or by an ID
+suffix such as analyzer.GoAnalyzer.Analyze. depth limits the number of
+hops, via the followed edge types, where the entities and match the IDs
+of the reported components.
+
+The table format lists the components found, or the hops of a path. The
+json and yaml formats write the subgraph connecting them to the
+components the query started from.
+
+Example:
+  archlint query 'callers(analyzer.GoAnalyzer.Analyze)'
+  archlint query 'path(internal/cli, pkg/tracer)'
+  archlint query 'rdeps(model.Node) depth 1 via uses where entity=struct'
+  archlint query -g arch.yaml -f yaml 'callees(cli.runCollect) depth 2'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runQuery,
+}
+
+func init() {
+	queryCmd.Flags().StringVarP(&queryGraphFile, "graph", "g",
+		"architecture.yaml", "Graph file produced by collect, YAML or JSON")
+	queryCmd.Flags().StringVarP(&queryFormat, "format", "f",
+		formatTable, "Output format: table, json or yaml")
+	rootCmd.AddCommand(queryCmd)
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	tracer.Enter("cli.runQuery")
+
+	switch queryFormat {
+	case formatTable, formatJSON, formatYAML:
+	default:
+		tracer.ExitError("cli.runQuery", errUnknownFormat)
+		return fmt.Errorf("%w: %s", errUnknownFormat, queryFormat)
+	}
+
+	query, err := model.ParseQuery(args[0])
+	if err != nil {
+		tracer.ExitError("cli.runQuery", err)
+		return err
+	}
+
+	graph, err := model.LoadGraph(queryGraphFile)
+	if err != nil {
+		tracer.ExitError("cli.runQuery", err)
+		return err
+	}
+
+	result, err := query.Run(model.NewIndex(graph))
+	if err != nil {
+		tracer.ExitError("cli.runQuery", err)
+		return err
+	}
+
+	if err := printQueryResult(query, result); err != nil {
+		tracer.ExitError("cli.runQuery", err)
+		return err
+	}
+
+	tracer.ExitSuccess("cli.runQuery")
+	return nil
+}
+
+func printQueryResult(query *model.Query, result *model.QueryResult) error {
+	tracer.Enter("cli.printQueryResult")
+
+	switch queryFormat {
+	case formatJSON:
+		data, err := json.MarshalIndent(result.Graph, "", "  ")
+		if err != nil {
+			tracer.ExitError("cli.printQueryResult", err)
+			return fmt.Errorf("failed to serialize JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case formatYAML:
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(result.Graph); err != nil {
+			tracer.ExitError("cli.printQueryResult", err)
+			return fmt.Errorf("%w: %v", errYAMLSerialization, err)
+		}
+		if err := encoder.Close(); err != nil {
+			tracer.ExitError("cli.printQueryResult", err)
+			return fmt.Errorf("%w: %v", errYAMLSerialization, err)
+		}
+	default:
+		printQueryTable(query, result)
+	}
+
+	tracer.ExitSuccess("cli.printQueryResult")
+	return nil
+}
+
+func printQueryTable(query *model.Query, result *model.QueryResult) {
+	tracer.Enter("cli.printQueryTable")
+
+	if query.Op == model.QueryPath {
+		fmt.Printf("Path length: %d\n", len(result.Graph.Edges))
+		for _, edge := range result.Graph.Edges {
+			fmt.Printf("  %s --%s--> %s\n", edge.From, edge.Type, edge.To)
+		}
+		tracer.ExitSuccess("cli.printQueryTable")
+		return
+	}
+
+	fmt.Printf("Components: %d\n", len(result.Nodes))
+	for _, node := range result.Nodes {
+		location := ""
+		if node.File != "" {
+			location = fmt.Sprintf("  (%s:%d)", node.File, node.Line)
+		}
+		fmt.Printf("  %-9s %s%s\n", node.Entity, node.ID, location)
+	}
+
+	tracer.ExitSuccess("cli.printQueryTable")
+}
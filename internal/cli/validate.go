@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mshogin/archlint/internal/model"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+var errInvalidGraph = errors.New("graph has integrity problems")
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [graph file]",
+	Short: "Check the integrity of an architecture graph",
+	Long: `Checks that a graph file in YAML or JSON format is internally consistent
+and reports every problem with its line:
+
+  - components without id and duplicate ids
+  - unknown entity values
+  - links of unknown type or to or from unknown components
+  - cycles of contains links
+
+The command exits with a non-zero status when problems are found, so it
+can guard hand-edited and merged files in CI.
+
+Example:
+  archlint validate architecture.yaml`,
+	Args:         cobra.ExactArgs(1),
+	RunE:         runValidate,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	tracer.Enter("cli.runValidate")
+
+	filename := args[0]
+
+	problems, err := model.ValidateFile(filename)
+	if err != nil {
+		tracer.ExitError("cli.runValidate", err)
+		return err
+	}
+
+	for _, problem := range problems {
+		fmt.Printf("%s:%s\n", filename, problem)
+	}
+
+	if len(problems) > 0 {
+		err := fmt.Errorf("%w: %d found in %s", errInvalidGraph, len(problems), filename)
+		tracer.ExitError("cli.runValidate", err)
+		return err
+	}
+
+	fmt.Printf("%s: ok\n", filename)
+
+	tracer.ExitSuccess("cli.runValidate")
+	return nil
+}
@@ -0,0 +1,185 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// GraphDiff lists the differences between two graphs. Components are
+// compared by ID and links by type and endpoints, so the order of either
+// graph does not matter. All lists are sorted.
+type GraphDiff struct {
+	AddedNodes   []Node       `yaml:"added_components,omitempty" json:"added_components,omitempty"`
+	RemovedNodes []Node       `yaml:"removed_components,omitempty" json:"removed_components,omitempty"`
+	ChangedNodes []NodeChange `yaml:"changed_components,omitempty" json:"changed_components,omitempty"`
+	AddedEdges   []Edge       `yaml:"added_links,omitempty" json:"added_links,omitempty"`
+	RemovedEdges []Edge       `yaml:"removed_links,omitempty" json:"removed_links,omitempty"`
+	// NewDependencies lists the pairs of packages or external components
+	// that are linked in the new graph but were not in the old one.
+	NewDependencies []Dependency `yaml:"new_dependencies,omitempty" json:"new_dependencies,omitempty"`
+}
+
+// NodeChange is a component whose entity changed.
+type NodeChange struct {
+	ID        string `yaml:"id" json:"id"`
+	OldEntity string `yaml:"old_entity" json:"old_entity"`
+	NewEntity string `yaml:"new_entity" json:"new_entity"`
+}
+
+// Dependency is a link from one package to another.
+type Dependency struct {
+	From string `yaml:"from" json:"from"`
+	To   string `yaml:"to" json:"to"`
+}
+
+// Empty reports whether the graphs are equal.
+func (d *GraphDiff) Empty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 && len(d.ChangedNodes) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0
+}
+
+// EdgeTypes returns the types of the added and removed links.
+func (d *GraphDiff) EdgeTypes() []string {
+	types := make(map[string]bool)
+	for _, edges := range [][]Edge{d.AddedEdges, d.RemovedEdges} {
+		for _, edge := range edges {
+			types[edge.Type] = true
+		}
+	}
+
+	return sortedKeys(types)
+}
+
+// Diff compares the old graph with the new one.
+func Diff(old, updated *Graph) *GraphDiff {
+	tracer.Enter("model.Diff")
+
+	oldIndex, newIndex := NewIndex(old), NewIndex(updated)
+	diff := &GraphDiff{}
+
+	for _, id := range sortedIDs(updated) {
+		node, _ := newIndex.Node(id)
+		oldNode, existed := oldIndex.Node(id)
+		switch {
+		case !existed:
+			diff.AddedNodes = append(diff.AddedNodes, node)
+		case oldNode.Entity != node.Entity:
+			diff.ChangedNodes = append(diff.ChangedNodes, NodeChange{
+				ID:        id,
+				OldEntity: oldNode.Entity,
+				NewEntity: node.Entity,
+			})
+		}
+	}
+
+	for _, id := range sortedIDs(old) {
+		if _, exists := newIndex.Node(id); !exists {
+			node, _ := oldIndex.Node(id)
+			diff.RemovedNodes = append(diff.RemovedNodes, node)
+		}
+	}
+
+	oldEdges, newEdges := edgeSet(old), edgeSet(updated)
+	diff.AddedEdges = edgesMissing(newEdges, oldEdges)
+	diff.RemovedEdges = edgesMissing(oldEdges, newEdges)
+
+	oldDeps := packageDependencies(oldIndex, old.Edges)
+	for _, dep := range sortedDependencies(packageDependencies(newIndex, diff.AddedEdges)) {
+		if !oldDeps[dep] {
+			diff.NewDependencies = append(diff.NewDependencies, dep)
+		}
+	}
+
+	tracer.ExitSuccess("model.Diff")
+	return diff
+}
+
+func sortedIDs(graph *Graph) []string {
+	ids := make(map[string]bool, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		ids[node.ID] = true
+	}
+
+	return sortedKeys(ids)
+}
+
+// edgeKey identifies a link regardless of its position in the graph.
+type edgeKey struct {
+	edgeType, from, to string
+}
+
+func edgeSet(graph *Graph) map[edgeKey]Edge {
+	set := make(map[edgeKey]Edge, len(graph.Edges))
+	for _, edge := range graph.Edges {
+		key := edgeKey{edge.Type, edge.From, edge.To}
+		if _, exists := set[key]; !exists {
+			set[key] = edge
+		}
+	}
+
+	return set
+}
+
+// edgesMissing returns the edges of set that are not in other, sorted by
+// type and endpoints.
+func edgesMissing(set, other map[edgeKey]Edge) []Edge {
+	var keys []edgeKey
+	for key := range set {
+		if _, exists := other[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].edgeType != keys[j].edgeType {
+			return keys[i].edgeType < keys[j].edgeType
+		}
+		if keys[i].from != keys[j].from {
+			return keys[i].from < keys[j].from
+		}
+		return keys[i].to < keys[j].to
+	})
+
+	edges := make([]Edge, 0, len(keys))
+	for _, key := range keys {
+		edges = append(edges, set[key])
+	}
+
+	return edges
+}
+
+// packageDependencies returns the pairs of distinct packages linked by
+// edges other than contains.
+func packageDependencies(ix *Index, edges []Edge) map[Dependency]bool {
+	deps := make(map[Dependency]bool)
+
+	for _, edge := range edges {
+		if edge.Type == "contains" {
+			continue
+		}
+
+		from, to := ix.Package(edge.From), ix.Package(edge.To)
+		if from != "" && to != "" && from != to {
+			deps[Dependency{From: from, To: to}] = true
+		}
+	}
+
+	return deps
+}
+
+func sortedDependencies(set map[Dependency]bool) []Dependency {
+	deps := make([]Dependency, 0, len(set))
+	for dep := range set {
+		deps = append(deps, dep)
+	}
+
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].From != deps[j].From {
+			return deps[i].From < deps[j].From
+		}
+		return deps[i].To < deps[j].To
+	})
+
+	return deps
+}
@@ -0,0 +1,281 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// Index is a read-only view of a Graph with nodes and edges indexed by
+// component ID. Methods taking edge types only follow edges of those
+// types; without edge types every edge is followed. Edge lists keep the
+// order of the graph and ID lists are sorted.
+type Index struct {
+	graph *Graph
+	nodes map[string]int // ID -> position in graph.Nodes
+	out   map[string][]Edge
+	in    map[string][]Edge
+}
+
+// NewIndex indexes graph. The graph must not be modified while the index
+// is in use.
+func NewIndex(graph *Graph) *Index {
+	tracer.Enter("model.NewIndex")
+
+	ix := &Index{
+		graph: graph,
+		nodes: make(map[string]int, len(graph.Nodes)),
+		out:   make(map[string][]Edge),
+		in:    make(map[string][]Edge),
+	}
+
+	for i, node := range graph.Nodes {
+		if _, exists := ix.nodes[node.ID]; !exists {
+			ix.nodes[node.ID] = i
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		ix.out[edge.From] = append(ix.out[edge.From], edge)
+		ix.in[edge.To] = append(ix.in[edge.To], edge)
+	}
+
+	tracer.ExitSuccess("model.NewIndex")
+	return ix
+}
+
+// Graph returns the indexed graph.
+func (ix *Index) Graph() *Graph {
+	return ix.graph
+}
+
+// Node returns the component with the given ID.
+func (ix *Index) Node(id string) (Node, bool) {
+	i, exists := ix.nodes[id]
+	if !exists {
+		return Node{}, false
+	}
+
+	return ix.graph.Nodes[i], true
+}
+
+// OutEdges returns the edges leaving id.
+func (ix *Index) OutEdges(id string, edgeTypes ...string) []Edge {
+	return filterEdges(ix.out[id], edgeTypes)
+}
+
+// InEdges returns the edges entering id.
+func (ix *Index) InEdges(id string, edgeTypes ...string) []Edge {
+	return filterEdges(ix.in[id], edgeTypes)
+}
+
+// Successors returns the targets of the edges leaving id.
+func (ix *Index) Successors(id string, edgeTypes ...string) []string {
+	return ix.Dependencies(id, 1, edgeTypes...)
+}
+
+// Predecessors returns the sources of the edges entering id.
+func (ix *Index) Predecessors(id string, edgeTypes ...string) []string {
+	return ix.Dependents(id, 1, edgeTypes...)
+}
+
+// Neighbors returns the components linked to id in either direction.
+func (ix *Index) Neighbors(id string, edgeTypes ...string) []string {
+	seen := make(map[string]bool)
+	for _, edge := range ix.OutEdges(id, edgeTypes...) {
+		seen[edge.To] = true
+	}
+	for _, edge := range ix.InEdges(id, edgeTypes...) {
+		seen[edge.From] = true
+	}
+	delete(seen, id)
+
+	return sortedKeys(seen)
+}
+
+// Dependencies returns the components reachable from id along outgoing
+// edges, up to depth edges away. A depth of zero or less is unlimited.
+// id itself is only included when it lies on a cycle.
+func (ix *Index) Dependencies(id string, depth int, edgeTypes ...string) []string {
+	tracer.Enter("model.Index.Dependencies")
+
+	ids := ix.reach(id, depth, ix.out, func(e Edge) string { return e.To }, edgeTypes)
+
+	tracer.ExitSuccess("model.Index.Dependencies")
+	return ids
+}
+
+// Dependents returns the components id is reachable from along incoming
+// edges, up to depth edges away. A depth of zero or less is unlimited.
+// id itself is only included when it lies on a cycle.
+func (ix *Index) Dependents(id string, depth int, edgeTypes ...string) []string {
+	tracer.Enter("model.Index.Dependents")
+
+	ids := ix.reach(id, depth, ix.in, func(e Edge) string { return e.From }, edgeTypes)
+
+	tracer.ExitSuccess("model.Index.Dependents")
+	return ids
+}
+
+// reach walks adjacency breadth-first from id and returns the visited
+// components.
+func (ix *Index) reach(id string, depth int, adjacency map[string][]Edge, next func(Edge) string, edgeTypes []string) []string {
+	seen := make(map[string]bool)
+	frontier := []string{id}
+
+	for level := 0; len(frontier) > 0 && (depth <= 0 || level < depth); level++ {
+		var following []string
+		for _, current := range frontier {
+			for _, edge := range filterEdges(adjacency[current], edgeTypes) {
+				target := next(edge)
+				if !seen[target] {
+					seen[target] = true
+					following = append(following, target)
+				}
+			}
+		}
+		frontier = following
+	}
+
+	return sortedKeys(seen)
+}
+
+// ShortestPath returns the edges of a shortest path from one component to
+// another along outgoing edges, or nil when there is none or from equals
+// to. Among paths of equal length the one using earlier edges of the
+// graph wins.
+func (ix *Index) ShortestPath(from, to string, edgeTypes ...string) []Edge {
+	tracer.Enter("model.Index.ShortestPath")
+
+	via := map[string]Edge{}
+	visited := map[string]bool{from: true}
+	queue := []string{from}
+
+	for len(queue) > 0 && !visited[to] {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range ix.OutEdges(current, edgeTypes...) {
+			if visited[edge.To] {
+				continue
+			}
+			visited[edge.To] = true
+			via[edge.To] = edge
+			queue = append(queue, edge.To)
+		}
+	}
+
+	if from == to || !visited[to] {
+		tracer.ExitSuccess("model.Index.ShortestPath")
+		return nil
+	}
+
+	var path []Edge
+	for id := to; id != from; id = via[id].From {
+		path = append(path, via[id])
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	tracer.ExitSuccess("model.Index.ShortestPath")
+	return path
+}
+
+// Subgraph returns the components whose IDs match any of the patterns,
+// with the semantics of tracer.MatchComponentPattern, and the edges
+// between them.
+func (ix *Index) Subgraph(patterns ...string) *Graph {
+	tracer.Enter("model.Index.Subgraph")
+
+	keep := make(map[string]bool)
+	sub := &Graph{}
+
+	for _, node := range ix.graph.Nodes {
+		for _, pattern := range patterns {
+			if tracer.MatchComponentPattern(node.ID, pattern) {
+				keep[node.ID] = true
+				sub.Nodes = append(sub.Nodes, node)
+				break
+			}
+		}
+	}
+
+	for _, edge := range ix.graph.Edges {
+		if keep[edge.From] && keep[edge.To] {
+			sub.Edges = append(sub.Edges, edge)
+		}
+	}
+
+	tracer.ExitSuccess("model.Index.Subgraph")
+	return sub
+}
+
+// Reverse returns an index of the graph with every edge reversed, so that
+// dependencies become dependents.
+func (ix *Index) Reverse() *Index {
+	tracer.Enter("model.Index.Reverse")
+
+	reversed := &Graph{
+		Nodes: ix.graph.Nodes,
+		Edges: make([]Edge, len(ix.graph.Edges)),
+	}
+	for i, edge := range ix.graph.Edges {
+		edge.From, edge.To = edge.To, edge.From
+		reversed.Edges[i] = edge
+	}
+
+	tracer.ExitSuccess("model.Index.Reverse")
+	return NewIndex(reversed)
+}
+
+// Package returns the package or external component id belongs to,
+// following contains links upwards, or "" when there is none. Packages
+// and external components belong to themselves.
+func (ix *Index) Package(id string) string {
+	visited := make(map[string]bool)
+
+	for id != "" && !visited[id] {
+		visited[id] = true
+
+		if node, ok := ix.Node(id); ok && (node.Entity == "package" || node.Entity == "external") {
+			return id
+		}
+
+		parents := ix.InEdges(id, "contains")
+		if len(parents) == 0 {
+			return ""
+		}
+		id = parents[0].From
+	}
+
+	return ""
+}
+
+func filterEdges(edges []Edge, edgeTypes []string) []Edge {
+	if len(edgeTypes) == 0 {
+		return edges
+	}
+
+	var filtered []Edge
+	for _, edge := range edges {
+		for _, edgeType := range edgeTypes {
+			if edge.Type == edgeType {
+				filtered = append(filtered, edge)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -1,25 +1,113 @@
 // Package model defines data structures for representing architecture graphs.
 package model
 
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownEntity is returned by Node.Validate for entity types not listed
+// in Entities.
+var ErrUnknownEntity = errors.New("unknown entity")
+
+// Entities lists the known entity types of components.
+var Entities = []string{
+	"module", "package", "external",
+	"struct", "interface", "alias", "functype",
+	"map", "slice", "array", "chan", "pointer", "basic", "named",
+	"function", "method", "test",
+	"var", "const", "field",
+}
+
+// EdgeTypes lists the known link types.
+var EdgeTypes = []string{
+	"contains", "import", "calls", "spawns", "defers", "references",
+	"uses", "embeds", "implements", "instantiates", "constrained-by",
+	"accepts", "returns", "reads", "writes", "sends", "receives", "tests",
+	"mentions",
+}
+
 // Graph represents an architecture graph with components (nodes) and links (edges).
+// Schema is the SchemaVersion of the file format and Metadata describes how
+// the graph was produced; both are empty for graphs built in memory.
 type Graph struct {
-	Nodes []Node `yaml:"components"`
-	Edges []Edge `yaml:"links"`
+	Schema   int       `yaml:"schema,omitempty" json:"schema,omitempty"`
+	Metadata *Metadata `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	Nodes    []Node    `yaml:"components" json:"components"`
+	Edges    []Edge    `yaml:"links" json:"links"`
 }
 
 // Node represents a component in the architecture graph.
-// Entity types: package, struct, interface, function, method, external.
+// Entity is one of Entities. Type declarations are classified by their
+// type expression: struct, interface, alias (type A = B), functype, map,
+// slice, array, chan, pointer, basic for named basic types and named for
+// types defined from another named type. Underlying holds the type
+// expression of the non-struct, non-interface kinds and the channel type
+// of channel variables and fields. Channel-typed struct fields are the
+// only "field" components.
+// File, Line and EndLine locate declarations in the source, with File
+// relative to the module root. Exported is set for declarations only. Doc
+// holds the first sentence of the doc comment and Signature the
+// declaration of functions and methods without the body.
+// Mutable flags package-level variables that functions write to.
+// Version and Replace are set on external components from go.mod.
+// Platforms lists the GOOS/GOARCH pairs a component exists on when the
+// graph was collected across several platforms.
+// TypeParams lists the type parameters of generic types and functions
+// with their constraints, e.g. "K comparable".
+// Tags holds markers added by later analyses, such as "unreachable" from
+// archlint deadcode.
 type Node struct {
-	ID     string `yaml:"id"`
-	Title  string `yaml:"title"`
-	Entity string `yaml:"entity"`
+	ID         string   `yaml:"id" json:"id"`
+	Title      string   `yaml:"title" json:"title"`
+	Entity     string   `yaml:"entity" json:"entity"`
+	Underlying string   `yaml:"underlying,omitempty" json:"underlying,omitempty"`
+	File       string   `yaml:"file,omitempty" json:"file,omitempty"`
+	Line       int      `yaml:"line,omitempty" json:"line,omitempty"`
+	EndLine    int      `yaml:"end_line,omitempty" json:"end_line,omitempty"`
+	Exported   *bool    `yaml:"exported,omitempty" json:"exported,omitempty"`
+	Doc        string   `yaml:"doc,omitempty" json:"doc,omitempty"`
+	Signature  string   `yaml:"signature,omitempty" json:"signature,omitempty"`
+	Mutable    bool     `yaml:"mutable,omitempty" json:"mutable,omitempty"`
+	Version    string   `yaml:"version,omitempty" json:"version,omitempty"`
+	Replace    string   `yaml:"replace,omitempty" json:"replace,omitempty"`
+	TypeParams []string `yaml:"type_params,omitempty" json:"type_params,omitempty"`
+	Platforms  []string `yaml:"platforms,omitempty" json:"platforms,omitempty"`
+	Tags       []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+}
+
+// Validate reports an error when the node has an unknown entity type.
+func (n Node) Validate() error {
+	for _, entity := range Entities {
+		if n.Entity == entity {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w %q of component %s", ErrUnknownEntity, n.Entity, n.ID)
 }
 
 // Edge represents a link between components in the architecture graph.
-// Type values: contains, calls, uses, embeds, import.
+// Type is one of EdgeTypes. Calls started by go statements are
+// spawns edges and deferred calls defers edges instead of calls edges.
+// References edges link code to the functions and methods it uses as
+// values, such as callbacks and handlers.
+// Uses edges link types to the types of their fields. Mentions edges,
+// collected for archlint deadcode, link functions and methods to the types
+// named in their bodies.
+// TypeArgs holds the type arguments of an instantiates edge. Algorithm
+// names the call graph algorithm (static, cha, rta, vta) that found a
+// calls, spawns or defers edge; it is empty for calls collected from the
+// syntax. Symbols lists the symbols an import edge's package uses from
+// the imported one and Count the number of their uses; both are only set
+// when collected with import detail.
 type Edge struct {
-	From   string `yaml:"from"`
-	To     string `yaml:"to"`
-	Method string `yaml:"method,omitempty"`
-	Type   string `yaml:"type,omitempty"`
+	From      string   `yaml:"from" json:"from"`
+	To        string   `yaml:"to" json:"to"`
+	Method    string   `yaml:"method,omitempty" json:"method,omitempty"`
+	Type      string   `yaml:"type,omitempty" json:"type,omitempty"`
+	TypeArgs  []string `yaml:"type_args,omitempty" json:"type_args,omitempty"`
+	Algorithm string   `yaml:"algorithm,omitempty" json:"algorithm,omitempty"`
+	Symbols   []string `yaml:"symbols,omitempty" json:"symbols,omitempty"`
+	Count     int      `yaml:"count,omitempty" json:"count,omitempty"`
 }
@@ -0,0 +1,406 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// Query errors.
+var (
+	ErrQuerySyntax = errors.New("invalid query")
+	ErrNoComponent = errors.New("no component matches")
+	ErrNoPath      = errors.New("no path found")
+)
+
+// Query operations.
+const (
+	QueryCallers = "callers"
+	QueryCallees = "callees"
+	QueryDeps    = "deps"
+	QueryRdeps   = "rdeps"
+	QueryPath    = "path"
+	QueryNodes   = "nodes"
+)
+
+// callEdgeTypes are the edges callers and callees follow by default.
+var callEdgeTypes = []string{"calls", "spawns", "defers"}
+
+// Query is a parsed graph query:
+//
+//	op(arg, ...) [depth N] [via type,...] [where entity=kind,...] [match pattern]
+//
+// op is one of callers, callees, deps, rdeps (transitive, with one
+// argument), path (with a start and a target argument) or nodes (with any
+// number of arguments). Arguments are component IDs, patterns for
+// tracer.MatchComponentPattern or ID suffixes following a "/" or ".",
+// e.g. analyzer.GoAnalyzer.Analyze. depth limits the traversal, via the
+// followed edge types, where the entities of the selected components and
+// match their IDs. callers and callees follow calls, spawns and defers
+// edges; the other operations follow every edge but contains.
+type Query struct {
+	Op       string
+	Args     []string
+	Depth    int
+	Via      []string
+	Entities []string
+	Match    string
+}
+
+// QueryResult holds the components a query selected, sorted by ID or in
+// path order, and the subgraph of the selected components, the components
+// the query started from and the followed edges between them.
+type QueryResult struct {
+	Nodes []Node
+	Graph *Graph
+}
+
+// ParseQuery parses a query expression, see Query.
+func ParseQuery(expr string) (*Query, error) {
+	tracer.Enter("model.ParseQuery")
+
+	tokens := tokenizeQuery(expr)
+	pos := 0
+
+	next := func() string {
+		if pos >= len(tokens) {
+			return ""
+		}
+		pos++
+		return tokens[pos-1]
+	}
+
+	// list reads comma-separated values.
+	list := func() []string {
+		values := []string{next()}
+		for pos < len(tokens) && tokens[pos] == "," {
+			pos++
+			values = append(values, next())
+		}
+		return values
+	}
+
+	q := &Query{Op: next()}
+
+	switch q.Op {
+	case QueryCallers, QueryCallees, QueryDeps, QueryRdeps, QueryPath, QueryNodes:
+	default:
+		err := fmt.Errorf("%w: unknown operation %q", ErrQuerySyntax, q.Op)
+		tracer.ExitError("model.ParseQuery", err)
+		return nil, err
+	}
+
+	if next() != "(" {
+		err := fmt.Errorf("%w: expected ( after %s", ErrQuerySyntax, q.Op)
+		tracer.ExitError("model.ParseQuery", err)
+		return nil, err
+	}
+	if pos < len(tokens) && tokens[pos] != ")" {
+		q.Args = list()
+	}
+	if next() != ")" {
+		err := fmt.Errorf("%w: expected ) after arguments", ErrQuerySyntax)
+		tracer.ExitError("model.ParseQuery", err)
+		return nil, err
+	}
+
+	for pos < len(tokens) {
+		var err error
+
+		switch clause := next(); clause {
+		case "depth":
+			q.Depth, err = strconv.Atoi(next())
+			if err != nil || q.Depth < 1 {
+				err = fmt.Errorf("%w: depth must be a positive number", ErrQuerySyntax)
+			}
+		case "via":
+			q.Via = list()
+		case "where":
+			if next() != "entity" || next() != "=" {
+				err = fmt.Errorf("%w: expected where entity=kind", ErrQuerySyntax)
+			}
+			q.Entities = list()
+		case "match":
+			q.Match = next()
+		default:
+			err = fmt.Errorf("%w: unexpected %q", ErrQuerySyntax, clause)
+		}
+
+		if err != nil {
+			tracer.ExitError("model.ParseQuery", err)
+			return nil, err
+		}
+	}
+
+	if err := q.check(); err != nil {
+		tracer.ExitError("model.ParseQuery", err)
+		return nil, err
+	}
+
+	tracer.ExitSuccess("model.ParseQuery")
+	return q, nil
+}
+
+// tokenizeQuery splits expr into words and the punctuation ( ) , =.
+func tokenizeQuery(expr string) []string {
+	var tokens []string
+	word := strings.Builder{}
+
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range expr {
+		switch r {
+		case ' ', '\t', '\n':
+			flush()
+		case '(', ')', ',', '=':
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// check validates the arguments and clauses of an operation.
+func (q *Query) check() error {
+	for _, values := range [][]string{q.Args, q.Via, q.Entities} {
+		for _, value := range values {
+			if value == "" || strings.ContainsAny(value, "(),=") {
+				return fmt.Errorf("%w: missing value", ErrQuerySyntax)
+			}
+		}
+	}
+
+	switch q.Op {
+	case QueryPath:
+		if len(q.Args) != 2 {
+			return fmt.Errorf("%w: path takes a start and a target", ErrQuerySyntax)
+		}
+		if q.Depth != 0 {
+			return fmt.Errorf("%w: depth does not apply to path", ErrQuerySyntax)
+		}
+	case QueryNodes:
+		if len(q.Args) == 0 {
+			return fmt.Errorf("%w: nodes takes at least one pattern", ErrQuerySyntax)
+		}
+		if q.Depth != 0 || q.Via != nil {
+			return fmt.Errorf("%w: depth and via do not apply to nodes", ErrQuerySyntax)
+		}
+	default:
+		if len(q.Args) != 1 {
+			return fmt.Errorf("%w: %s takes one component", ErrQuerySyntax, q.Op)
+		}
+	}
+
+	return nil
+}
+
+// Run evaluates the query against ix.
+func (q *Query) Run(ix *Index) (*QueryResult, error) {
+	tracer.Enter("model.Query.Run")
+
+	var starts [][]string
+	for _, arg := range q.Args {
+		ids := ix.Resolve(arg)
+		if len(ids) == 0 {
+			err := fmt.Errorf("%w: %s", ErrNoComponent, arg)
+			tracer.ExitError("model.Query.Run", err)
+			return nil, err
+		}
+		starts = append(starts, ids)
+	}
+
+	edgeTypes := q.edgeTypes(ix)
+
+	if q.Op == QueryPath {
+		result, err := q.runPath(ix, starts[0], starts[1], edgeTypes)
+		if err != nil {
+			tracer.ExitError("model.Query.Run", err)
+			return nil, err
+		}
+		tracer.ExitSuccess("model.Query.Run")
+		return result, nil
+	}
+
+	selected := make(map[string]bool)
+	roots := make(map[string]bool)
+
+	for _, id := range starts[0] {
+		var ids []string
+		switch q.Op {
+		case QueryCallers, QueryRdeps:
+			ids = ix.Dependents(id, q.Depth, edgeTypes...)
+			roots[id] = true
+		case QueryCallees, QueryDeps:
+			ids = ix.Dependencies(id, q.Depth, edgeTypes...)
+			roots[id] = true
+		}
+		for _, dep := range ids {
+			selected[dep] = true
+		}
+	}
+
+	if q.Op == QueryNodes {
+		for _, ids := range starts {
+			for _, id := range ids {
+				selected[id] = true
+			}
+		}
+	}
+
+	result := &QueryResult{Graph: &Graph{}}
+	keep := make(map[string]bool)
+
+	for _, id := range sortedKeys(selected) {
+		node := ix.nodeOrStub(id)
+		if !q.accepts(node) {
+			continue
+		}
+		result.Nodes = append(result.Nodes, node)
+		keep[id] = true
+	}
+
+	for _, id := range sortedKeys(roots) {
+		if !keep[id] {
+			keep[id] = true
+			result.Graph.Nodes = append(result.Graph.Nodes, ix.nodeOrStub(id))
+		}
+	}
+	result.Graph.Nodes = append(result.Graph.Nodes, result.Nodes...)
+
+	if q.Op != QueryNodes {
+		for _, edge := range ix.graph.Edges {
+			if keep[edge.From] && keep[edge.To] && len(filterEdges([]Edge{edge}, edgeTypes)) > 0 {
+				result.Graph.Edges = append(result.Graph.Edges, edge)
+			}
+		}
+	}
+
+	tracer.ExitSuccess("model.Query.Run")
+	return result, nil
+}
+
+// runPath finds the shortest path from any start to any target.
+func (q *Query) runPath(ix *Index, from, to, edgeTypes []string) (*QueryResult, error) {
+	var best []Edge
+	for _, start := range from {
+		for _, target := range to {
+			path := ix.ShortestPath(start, target, edgeTypes...)
+			if path != nil && (best == nil || len(path) < len(best)) {
+				best = path
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrNoPath, q.Args[0], q.Args[1])
+	}
+
+	result := &QueryResult{Graph: &Graph{Edges: best}}
+	ids := []string{best[0].From}
+	for _, edge := range best {
+		ids = append(ids, edge.To)
+	}
+
+	for _, id := range ids {
+		node := ix.nodeOrStub(id)
+		result.Graph.Nodes = append(result.Graph.Nodes, node)
+		if q.accepts(node) {
+			result.Nodes = append(result.Nodes, node)
+		}
+	}
+
+	return result, nil
+}
+
+// edgeTypes returns the edge types the query follows.
+func (q *Query) edgeTypes(ix *Index) []string {
+	if q.Via != nil {
+		return q.Via
+	}
+
+	switch q.Op {
+	case QueryCallers, QueryCallees:
+		return callEdgeTypes
+	case QueryNodes:
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, edge := range ix.graph.Edges {
+		if edge.Type != "contains" {
+			seen[edge.Type] = true
+		}
+	}
+
+	return sortedKeys(seen)
+}
+
+// accepts applies the where and match clauses.
+func (q *Query) accepts(node Node) bool {
+	if q.Match != "" && !tracer.MatchComponentPattern(node.ID, q.Match) {
+		return false
+	}
+
+	if q.Entities == nil {
+		return true
+	}
+
+	for _, entity := range q.Entities {
+		if node.Entity == entity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolve returns the IDs of the components arg denotes: the component
+// with that ID, the components matching arg as a pattern or, failing
+// both, the components whose ID ends in arg after a "/" or ".".
+func (ix *Index) Resolve(arg string) []string {
+	if _, exists := ix.nodes[arg]; exists {
+		return []string{arg}
+	}
+
+	var ids []string
+	for _, node := range ix.graph.Nodes {
+		if strings.Contains(arg, "*") && tracer.MatchComponentPattern(node.ID, arg) {
+			ids = append(ids, node.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		for _, node := range ix.graph.Nodes {
+			if strings.HasSuffix(node.ID, "/"+arg) || strings.HasSuffix(node.ID, "."+arg) {
+				ids = append(ids, node.ID)
+			}
+		}
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// nodeOrStub returns the component with the given ID, or a component
+// holding only the ID for edge endpoints without a component.
+func (ix *Index) nodeOrStub(id string) Node {
+	if node, ok := ix.Node(id); ok {
+		return node
+	}
+
+	return Node{ID: id}
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// SchemaVersion is the version of the graph file format written by this
+// package. Version 1 is the bare graph of components and links written
+// before files carried a schema version and metadata.
+const SchemaVersion = 2
+
+// Graph file errors.
+var (
+	ErrGraphRead         = errors.New("failed to read graph")
+	ErrUnsupportedSchema = errors.New("unsupported graph schema version")
+)
+
+// Metadata describes how a graph file was produced: the archlint version,
+// the analyzed module, its VCS revision, the generation time in RFC 3339
+// format and the analysis options that differ from the defaults. Module
+// is empty at the root of a go.work workspace; Modules lists the modules
+// when more than one was analyzed.
+type Metadata struct {
+	ArchlintVersion string            `yaml:"archlint_version,omitempty" json:"archlint_version,omitempty"`
+	Module          string            `yaml:"module,omitempty" json:"module,omitempty"`
+	Modules         []string          `yaml:"modules,omitempty" json:"modules,omitempty"`
+	Revision        string            `yaml:"revision,omitempty" json:"revision,omitempty"`
+	Dirty           bool              `yaml:"dirty,omitempty" json:"dirty,omitempty"`
+	GeneratedAt     string            `yaml:"generated_at,omitempty" json:"generated_at,omitempty"`
+	Options         map[string]string `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+// LoadGraph reads a graph file in YAML or JSON format and migrates it to
+// SchemaVersion.
+func LoadGraph(filename string) (*Graph, error) {
+	tracer.Enter("model.LoadGraph")
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		tracer.ExitError("model.LoadGraph", err)
+		return nil, fmt.Errorf("%w: %v", ErrGraphRead, err)
+	}
+
+	graph, err := DecodeGraph(data)
+	if err != nil {
+		tracer.ExitError("model.LoadGraph", err)
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	tracer.ExitSuccess("model.LoadGraph")
+	return graph, nil
+}
+
+// DecodeGraph decodes a graph in YAML or JSON format, telling them apart
+// by the leading "{" of JSON documents, and migrates it to SchemaVersion.
+func DecodeGraph(data []byte) (*Graph, error) {
+	tracer.Enter("model.DecodeGraph")
+
+	var graph Graph
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		if err := json.Unmarshal(data, &graph); err != nil {
+			tracer.ExitError("model.DecodeGraph", err)
+			return nil, fmt.Errorf("%w: %v", ErrGraphRead, err)
+		}
+	} else if err := yaml.Unmarshal(data, &graph); err != nil {
+		tracer.ExitError("model.DecodeGraph", err)
+		return nil, fmt.Errorf("%w: %v", ErrGraphRead, err)
+	}
+
+	if err := graph.Migrate(); err != nil {
+		tracer.ExitError("model.DecodeGraph", err)
+		return nil, err
+	}
+
+	tracer.ExitSuccess("model.DecodeGraph")
+	return &graph, nil
+}
+
+// Migrate upgrades a decoded graph to SchemaVersion. A graph without a
+// schema version is taken to be version 1.
+func (g *Graph) Migrate() error {
+	tracer.Enter("model.Graph.Migrate")
+
+	if g.Schema == 0 {
+		g.Schema = 1
+	}
+
+	if g.Schema < 0 || g.Schema > SchemaVersion {
+		err := fmt.Errorf("%w: %d (supported up to %d)", ErrUnsupportedSchema, g.Schema, SchemaVersion)
+		tracer.ExitError("model.Graph.Migrate", err)
+		return err
+	}
+
+	// Version 2 only added the optional schema and metadata header, so
+	// version 1 graphs load unchanged and stay without metadata.
+	g.Schema = SchemaVersion
+
+	tracer.ExitSuccess("model.Graph.Migrate")
+	return nil
+}
@@ -0,0 +1,214 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+// Problem is an integrity problem of a graph. Path locates the offending
+// component or link, e.g. "components[3]"; Line is its line in the graph
+// file, or zero when the graph was not read from a file.
+type Problem struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+// String formats the problem as "line: path: message".
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%d: %s: %s", p.Line, p.Path, p.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", p.Path, p.Message)
+}
+
+// Validate checks the integrity of the graph and returns every problem
+// found: components without ID, duplicate IDs, unknown entities, links
+// with unknown types or endpoints that are not components, and cycles
+// of contains links.
+func (g *Graph) Validate() []Problem {
+	tracer.Enter("model.Graph.Validate")
+
+	var problems []Problem
+
+	first := make(map[string]int, len(g.Nodes))
+	for i, node := range g.Nodes {
+		path := fmt.Sprintf("components[%d]", i)
+
+		if node.ID == "" {
+			problems = append(problems, Problem{Path: path, Message: "component without id"})
+			continue
+		}
+
+		if j, exists := first[node.ID]; exists {
+			problems = append(problems, Problem{
+				Path:    path,
+				Message: fmt.Sprintf("duplicate id %s, first declared at components[%d]", node.ID, j),
+			})
+		} else {
+			first[node.ID] = i
+		}
+
+		if err := node.Validate(); err != nil {
+			problems = append(problems, Problem{Path: path, Message: err.Error()})
+		}
+	}
+
+	for i, edge := range g.Edges {
+		path := fmt.Sprintf("links[%d]", i)
+
+		if !isEdgeType(edge.Type) {
+			problems = append(problems, Problem{
+				Path:    path,
+				Message: fmt.Sprintf("unknown link type %q of link %s -> %s", edge.Type, edge.From, edge.To),
+			})
+		}
+
+		for _, end := range []struct{ name, id string }{{"from", edge.From}, {"to", edge.To}} {
+			if _, exists := first[end.id]; !exists {
+				problems = append(problems, Problem{
+					Path:    path,
+					Message: fmt.Sprintf("%s refers to unknown component %q", end.name, end.id),
+				})
+			}
+		}
+	}
+
+	problems = append(problems, g.containsCycles()...)
+
+	tracer.ExitSuccess("model.Graph.Validate")
+	return problems
+}
+
+func isEdgeType(edgeType string) bool {
+	for _, known := range EdgeTypes {
+		if edgeType == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsCycles reports every cycle of contains links once, at the link
+// closing it.
+func (g *Graph) containsCycles() []Problem {
+	children := make(map[string][]int)
+	for i, edge := range g.Edges {
+		if edge.Type == "contains" {
+			children[edge.From] = append(children[edge.From], i)
+		}
+	}
+
+	const (
+		unvisited = iota
+		active
+		done
+	)
+
+	state := make(map[string]int)
+	var stack []string
+	var problems []Problem
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = active
+		stack = append(stack, id)
+
+		for _, i := range children[id] {
+			child := g.Edges[i].To
+
+			switch state[child] {
+			case unvisited:
+				visit(child)
+			case active:
+				start := len(stack) - 1
+				for stack[start] != child {
+					start--
+				}
+				cycle := append(append([]string{}, stack[start:]...), child)
+				problems = append(problems, Problem{
+					Path:    fmt.Sprintf("links[%d]", i),
+					Message: "contains cycle " + strings.Join(cycle, " -> "),
+				})
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+
+	for _, edge := range g.Edges {
+		if edge.Type == "contains" && state[edge.From] == unvisited {
+			visit(edge.From)
+		}
+	}
+
+	return problems
+}
+
+// ValidateFile reads a graph file in YAML or JSON format and returns its
+// integrity problems with their line numbers. The error is set when the
+// file cannot be read or decoded at all.
+func ValidateFile(filename string) ([]Problem, error) {
+	tracer.Enter("model.ValidateFile")
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		tracer.ExitError("model.ValidateFile", err)
+		return nil, fmt.Errorf("%w: %v", ErrGraphRead, err)
+	}
+
+	graph, err := DecodeGraph(data)
+	if err != nil {
+		tracer.ExitError("model.ValidateFile", err)
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	problems := graph.Validate()
+
+	lines, err := itemLines(data)
+	if err != nil {
+		tracer.ExitError("model.ValidateFile", err)
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	for i := range problems {
+		problems[i].Line = lines[problems[i].Path]
+	}
+
+	tracer.ExitSuccess("model.ValidateFile")
+	return problems, nil
+}
+
+// itemLines maps the paths of the components and links of a graph file,
+// e.g. "links[2]", to their line numbers. JSON is parsed as YAML.
+func itemLines(data []byte) (map[string]int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGraphRead, err)
+	}
+
+	lines := make(map[string]int)
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return lines, nil
+	}
+
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		if (key != "components" && key != "links") || value.Kind != yaml.SequenceNode {
+			continue
+		}
+		for j, item := range value.Content {
+			lines[fmt.Sprintf("%s[%d]", key, j)] = item.Line
+		}
+	}
+
+	return lines, nil
+}
@@ -60,6 +60,14 @@ func StopTrace() *Trace {
 	return trace
 }
 
+// Active reports whether a trace is being recorded.
+func Active() bool {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+
+	return currentTrace != nil
+}
+
 // Enter records entry into a function.
 func Enter(fn string) {
 	traceMu.Lock()
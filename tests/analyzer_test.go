@@ -0,0 +1,979 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/mshogin/archlint/internal/analyzer"
+	"github.com/mshogin/archlint/internal/model"
+)
+
+const layeredModule = "example.com/layered"
+
+// analyzeLayered runs the analyzer with opts over testdata/layered.
+func analyzeLayered(t *testing.T, opts analyzer.Options) *model.Graph {
+	t.Helper()
+
+	graph, err := analyzer.NewGoAnalyzerWithOptions(opts).Analyze(filepath.Join("testdata", "layered"))
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	return graph
+}
+
+// hasEdge reports whether graph contains an edge from -> to of the given type.
+func hasEdge(graph *model.Graph, from, to, edgeType string) bool {
+	for _, edge := range graph.Edges {
+		if edge.From == from && edge.To == to && edge.Type == edgeType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nodesByID indexes the components of graph by ID.
+func nodesByID(graph *model.Graph) map[string]model.Node {
+	nodes := make(map[string]model.Node, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+
+	return nodes
+}
+
+// forEachMode runs test as a subtest in syntactic and in type-checked mode.
+func forEachMode(t *testing.T, test func(t *testing.T, typeCheck bool)) {
+	t.Helper()
+
+	for _, typeCheck := range []bool{false, true} {
+		t.Run(fmt.Sprintf("typecheck=%v", typeCheck), func(t *testing.T) {
+			test(t, typeCheck)
+		})
+	}
+}
+
+// TestTypeCheckedCrossPackage verifies that type-checked analysis links
+// calls and field types to declarations in other packages.
+func TestTypeCheckedCrossPackage(t *testing.T) {
+	graph := analyzeLayered(t, analyzer.Options{TypeCheck: true})
+
+	tests := []struct {
+		from, to, edgeType string
+	}{
+		{layeredModule + "/service.NewDefault", layeredModule + "/store.NewMemoryStore", "calls"},
+		{layeredModule + "/service.NewDefault", layeredModule + "/service.New", "calls"},
+		{layeredModule + "/service.Service.Rename", layeredModule + "/service.validate", "calls"},
+		{layeredModule + "/service.Service.Rename", layeredModule + "/model.Repository.Get", "calls"},
+		{layeredModule + "/service.Service", layeredModule + "/store.MemoryStore", "uses"},
+		{layeredModule + "/service.Service", layeredModule + "/model.Repository", "uses"},
+		{layeredModule + "/store.MemoryStore", layeredModule + "/model.User", "uses"},
+	}
+
+	for _, tt := range tests {
+		if !hasEdge(graph, tt.from, tt.to, tt.edgeType) {
+			t.Errorf("missing %s edge %s -> %s", tt.edgeType, tt.from, tt.to)
+		}
+	}
+}
+
+// TestMethodCallEdges verifies that syntactic analysis infers receiver
+// types and links method calls, including calls on interface values.
+func TestMethodCallEdges(t *testing.T) {
+	graph := analyzeLayered(t, analyzer.Options{})
+
+	tests := []struct {
+		from, to string
+	}{
+		{layeredModule + "/store.MemoryStore.Seed", layeredModule + "/store.MemoryStore.Save"},
+		{layeredModule + "/store.NewSeeded", layeredModule + "/store.MemoryStore.Seed"},
+		{layeredModule + "/model.Directory.Lookup", layeredModule + "/model.Repository.Get"},
+	}
+
+	for _, tt := range tests {
+		if !hasEdge(graph, tt.from, tt.to, "calls") {
+			t.Errorf("missing calls edge %s -> %s", tt.from, tt.to)
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		if edge.Type == "calls" && edge.Method == "" {
+			t.Errorf("calls edge %s -> %s has no method", edge.From, edge.To)
+		}
+	}
+}
+
+// TestImplementsEdges verifies interface satisfaction across packages,
+// including interfaces that embed other interfaces.
+func TestImplementsEdges(t *testing.T) {
+	for _, opts := range []analyzer.Options{{}, {TypeCheck: true}} {
+		graph := analyzeLayered(t, opts)
+
+		for _, iface := range []string{"Repository", "Reader", "Cache"} {
+			if !hasEdge(graph, layeredModule+"/store.MemoryStore", layeredModule+"/model."+iface, "implements") {
+				t.Errorf("typecheck=%v: MemoryStore should implement %s", opts.TypeCheck, iface)
+			}
+		}
+
+		for _, edge := range graph.Edges {
+			if edge.Type == "implements" && edge.From == layeredModule+"/model.Directory" {
+				t.Errorf("typecheck=%v: Directory should not implement %s", opts.TypeCheck, edge.To)
+			}
+		}
+	}
+}
+
+// TestImplementsQualifiedTypes verifies that signatures are compared by
+// the types they denote rather than by their unqualified names.
+func TestImplementsQualifiedTypes(t *testing.T) {
+	const module = "example.com/qualified"
+
+	for _, opts := range []analyzer.Options{{}, {TypeCheck: true}} {
+		graph, err := analyzer.NewGoAnalyzerWithOptions(opts).Analyze(filepath.Join("testdata", "qualified"))
+		if err != nil {
+			t.Fatalf("Analyze failed: %v", err)
+		}
+
+		tests := []struct {
+			from, to string
+			want     bool
+		}{
+			{module + "/b.Repo", module + "/a.Repo", true},
+			{module + "/b.Store", module + "/a.Repo", false},
+			{module + "/b.Store", module + "/a.Sink", true},
+		}
+
+		for _, tt := range tests {
+			if got := hasEdge(graph, tt.from, tt.to, "implements"); got != tt.want {
+				t.Errorf("typecheck=%v: %s implements %s = %v, want %v", opts.TypeCheck, tt.from, tt.to, got, tt.want)
+			}
+		}
+	}
+}
+
+// TestExternalComponents verifies that third-party imports become external
+// components carrying the go.mod version.
+func TestExternalComponents(t *testing.T) {
+	graph := analyzeLayered(t, analyzer.Options{ExternalPackages: true})
+
+	nodes := nodesByID(graph)
+
+	module, ok := nodes["example.com/extlib"]
+	if !ok {
+		t.Fatal("expected external module component example.com/extlib")
+	}
+
+	if module.Entity != "external" || module.Version != "v1.2.0" || module.Replace != "../extlib" {
+		t.Errorf("unexpected module component: %+v", module)
+	}
+
+	if pkg := nodes["example.com/extlib/textutil"]; pkg.Entity != "external" || pkg.Version != "v1.2.0" {
+		t.Errorf("unexpected package component: %+v", pkg)
+	}
+
+	if !hasEdge(graph, layeredModule+"/service", "example.com/extlib/textutil", "import") {
+		t.Error("missing import edge from service to textutil")
+	}
+
+	if !hasEdge(graph, "example.com/extlib", "example.com/extlib/textutil", "contains") {
+		t.Error("missing contains edge from extlib module to textutil")
+	}
+}
+
+// TestCrossPackageTypeDependencies verifies that syntactic analysis maps
+// import aliases, renamed and dot imports to import paths.
+func TestCrossPackageTypeDependencies(t *testing.T) {
+	graph := analyzeLayered(t, analyzer.Options{})
+
+	tests := []struct {
+		from, to, edgeType string
+	}{
+		{layeredModule + "/service.Service", layeredModule + "/store.MemoryStore", "uses"},
+		{layeredModule + "/service.Service", layeredModule + "/model.Repository", "uses"},
+		{layeredModule + "/service.Audit", layeredModule + "/model.User", "uses"},
+		{layeredModule + "/service.Audit", "example.com/extlib", "uses"},
+		{layeredModule + "/service.NewDefault", layeredModule + "/store.NewMemoryStore", "calls"},
+		{layeredModule + "/service.Service.Rename", layeredModule + "/model.Repository.Get", "calls"},
+	}
+
+	for _, tt := range tests {
+		if !hasEdge(graph, tt.from, tt.to, tt.edgeType) {
+			t.Errorf("missing %s edge %s -> %s", tt.edgeType, tt.from, tt.to)
+		}
+	}
+}
+
+// TestTestLayer verifies that test functions become test components linked
+// to the production code they exercise, also through test helpers.
+func TestTestLayer(t *testing.T) {
+	for _, opts := range []analyzer.Options{{IncludeTests: true}, {IncludeTests: true, TypeCheck: true}} {
+		graph := analyzeLayered(t, opts)
+
+		entities := make(map[string]string)
+		for _, node := range graph.Nodes {
+			entities[node.ID] = node.Entity
+		}
+
+		for _, id := range []string{
+			layeredModule + "/store.TestGet",
+			layeredModule + "/store.BenchmarkLen",
+			layeredModule + "/service_test.ExampleService_Rename",
+		} {
+			if entities[id] != "test" {
+				t.Errorf("typecheck=%v: %s entity = %q, want test", opts.TypeCheck, id, entities[id])
+			}
+		}
+
+		if entities[layeredModule+"/store.newSeededStore"] != "function" {
+			t.Errorf("typecheck=%v: test helper should stay a function", opts.TypeCheck)
+		}
+
+		tests := []struct {
+			from, to string
+		}{
+			{layeredModule + "/store.TestGet", layeredModule + "/store.MemoryStore.Get"},
+			{layeredModule + "/store.TestGet", layeredModule + "/store.MemoryStore.Seed"},
+			{layeredModule + "/store.BenchmarkLen", layeredModule + "/store.MemoryStore.Len"},
+			{layeredModule + "/service_test.ExampleService_Rename", layeredModule + "/service.Service.Rename"},
+		}
+
+		for _, tt := range tests {
+			if !hasEdge(graph, tt.from, tt.to, "tests") {
+				t.Errorf("typecheck=%v: missing tests edge %s -> %s", opts.TypeCheck, tt.from, tt.to)
+			}
+		}
+	}
+
+	graph := analyzeLayered(t, analyzer.Options{})
+	for _, node := range graph.Nodes {
+		if node.Entity == "test" {
+			t.Errorf("unexpected test component %s without IncludeTests", node.ID)
+		}
+	}
+}
+
+// TestBuildConstraints verifies file selection by build context and the
+// platform tags of union mode.
+func TestBuildConstraints(t *testing.T) {
+	platformsOf := func(graph *model.Graph) map[string][]string {
+		result := make(map[string][]string)
+		for _, node := range graph.Nodes {
+			result[node.ID] = node.Platforms
+		}
+		return result
+	}
+
+	for _, typeCheck := range []bool{false, true} {
+		windows := platformsOf(analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck, GOOS: "windows", GOARCH: "amd64"}))
+		if _, ok := windows[layeredModule+"/store.lockFile"]; ok {
+			t.Errorf("typecheck=%v: linux-only lockFile collected for windows", typeCheck)
+		}
+		if _, ok := windows[layeredModule+"/store.defaultDir"]; !ok {
+			t.Errorf("typecheck=%v: defaultDir missing for windows", typeCheck)
+		}
+		if _, ok := windows[layeredModule+"/store.integrationDir"]; ok {
+			t.Errorf("typecheck=%v: integrationDir collected without integration tag", typeCheck)
+		}
+
+		tagged := platformsOf(analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck, GOOS: "linux", Tags: []string{"integration"}}))
+		if _, ok := tagged[layeredModule+"/store.integrationDir"]; !ok {
+			t.Errorf("typecheck=%v: integrationDir missing with integration tag", typeCheck)
+		}
+
+		union := platformsOf(analyzeLayered(t, analyzer.Options{
+			TypeCheck: typeCheck,
+			Platforms: []string{"linux/amd64", "windows/amd64"},
+		}))
+
+		want := map[string]string{
+			layeredModule + "/store.defaultDir":     "[linux/amd64 windows/amd64]",
+			layeredModule + "/store.lockFile":       "[linux/amd64]",
+			layeredModule + "/store.NewMemoryStore": "[linux/amd64 windows/amd64]",
+		}
+		for id, platforms := range want {
+			if got := fmt.Sprint(union[id]); got != platforms {
+				t.Errorf("typecheck=%v: %s platforms = %s, want %s", typeCheck, id, got, platforms)
+			}
+		}
+	}
+}
+
+// TestWorkspaceModules verifies that packages of every module in a go.work
+// workspace or nested go.mod get their module's import path.
+func TestWorkspaceModules(t *testing.T) {
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph, err := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{TypeCheck: typeCheck}).
+			Analyze(filepath.Join("testdata", "workspace"))
+		if err != nil {
+			t.Fatalf("Analyze failed: %v", err)
+		}
+
+		entities := make(map[string]string)
+		for _, node := range graph.Nodes {
+			entities[node.ID] = node.Entity
+		}
+
+		for id, entity := range map[string]string{
+			"module:example.com/app":   "module",
+			"module:example.com/lib":   "module",
+			"module:example.com/tools": "module",
+			"example.com/app":          "package",
+			"example.com/lib/greet":    "package",
+			"example.com/tools":        "package",
+		} {
+			if entities[id] != entity {
+				t.Errorf("component %s has entity %q, want %q", id, entities[id], entity)
+			}
+		}
+
+		if _, exists := entities["example.com/lib"]; exists {
+			t.Error("workspace module example.com/lib reported as external")
+		}
+
+		tests := []struct {
+			from, to, edgeType string
+		}{
+			{"module:example.com/app", "example.com/app", "contains"},
+			{"module:example.com/lib", "example.com/lib/greet", "contains"},
+			{"module:example.com/tools", "example.com/tools", "contains"},
+			{"example.com/app", "example.com/lib/greet", "import"},
+			{"example.com/app.main", "example.com/lib/greet.Hello", "calls"},
+		}
+
+		for _, tt := range tests {
+			if !hasEdge(graph, tt.from, tt.to, tt.edgeType) {
+				t.Errorf("missing %s edge %s -> %s", tt.edgeType, tt.from, tt.to)
+			}
+		}
+	})
+}
+
+// TestModulePath verifies that the module recorded in the graph metadata
+// does not depend on the analysis mode.
+func TestModulePath(t *testing.T) {
+	tests := []struct {
+		dir     string
+		module  string
+		modules []string
+	}{
+		{filepath.Join("testdata", "layered"), layeredModule, []string{layeredModule}},
+		{filepath.Join("testdata", "layered", "service"), layeredModule, []string{layeredModule}},
+		{filepath.Join("testdata", "workspace"), "", []string{"example.com/app", "example.com/lib", "example.com/tools"}},
+	}
+
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		for _, tt := range tests {
+			a := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{TypeCheck: typeCheck})
+			if _, err := a.Analyze(tt.dir); err != nil {
+				t.Fatalf("Analyze(%s) failed: %v", tt.dir, err)
+			}
+
+			if got := a.ModulePath(); got != tt.module {
+				t.Errorf("%s: module = %q, want %q", tt.dir, got, tt.module)
+			}
+			if got := a.Modules(); !reflect.DeepEqual(got, tt.modules) {
+				t.Errorf("%s: modules = %v, want %v", tt.dir, got, tt.modules)
+			}
+		}
+	})
+}
+
+// TestNestedModuleOutsideWorkspace verifies that a module below a go.work
+// that does not list it is analyzed on its own.
+func TestNestedModuleOutsideWorkspace(t *testing.T) {
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph, err := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{TypeCheck: typeCheck}).
+			Analyze(filepath.Join("testdata", "workspace", "tools"))
+		if err != nil {
+			t.Fatalf("Analyze failed: %v", err)
+		}
+
+		if !hasEdge(graph, "example.com/tools", "example.com/tools.Generate", "contains") {
+			t.Error("missing contains edge example.com/tools -> example.com/tools.Generate")
+		}
+	})
+}
+
+// TestUserGoWork verifies that a GOWORK set in the environment is passed
+// on to the go command instead of being replaced.
+func TestUserGoWork(t *testing.T) {
+	t.Setenv("GOWORK", filepath.Join(t.TempDir(), "go.work"))
+
+	_, err := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{TypeCheck: true}).
+		Analyze(filepath.Join("testdata", "workspace", "tools"))
+	if err == nil {
+		t.Error("Analyze succeeded although GOWORK names a missing go.work")
+	}
+}
+
+// TestSkippedDirectories verifies that both modes ignore the directories
+// the go command ignores.
+func TestSkippedDirectories(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, src string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("go.mod", "module example.com/skip\n\ngo 1.22\n")
+	write("skip.go", "package skip\n\nfunc Run() {}\n")
+	write("testdata/fixture/fixture.go", "package fixture\n\nfunc Fixture() {}\n")
+	write("testdata/nested/go.mod", "module example.com/nested\n\ngo 1.22\n")
+	write("testdata/nested/nested.go", "package nested\n\nfunc Nested() {}\n")
+	write("_tools/tools.go", "package tools\n\nfunc Tool() {}\n")
+	write(".hidden/hidden.go", "package hidden\n\nfunc Hidden() {}\n")
+
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph, err := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{TypeCheck: typeCheck}).Analyze(dir)
+		if err != nil {
+			t.Fatalf("Analyze failed: %v", err)
+		}
+
+		var ids []string
+		for _, node := range graph.Nodes {
+			ids = append(ids, node.ID)
+		}
+		sort.Strings(ids)
+
+		want := []string{"example.com/skip", "example.com/skip.Run", "module:example.com/skip"}
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("components = %v, want %v", ids, want)
+		}
+	})
+}
+
+// TestGenerics verifies type parameters, instantiation and constraint
+// edges of generic declarations.
+func TestGenerics(t *testing.T) {
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph := analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck})
+
+		params := make(map[string][]string)
+		for _, node := range graph.Nodes {
+			params[node.ID] = node.TypeParams
+		}
+
+		registry := layeredModule + "/model.Registry"
+		if got := fmt.Sprint(params[registry]); got != "[K comparable V any]" {
+			t.Errorf("Registry type params = %s", got)
+		}
+		if got := fmt.Sprint(params[layeredModule+"/model.Sum"]); got != "[T Number]" {
+			t.Errorf("Sum type params = %s", got)
+		}
+
+		instantiates := func(from, to string) []string {
+			for _, edge := range graph.Edges {
+				if edge.From == from && edge.To == to && edge.Type == "instantiates" {
+					return edge.TypeArgs
+				}
+			}
+			return nil
+		}
+
+		index := layeredModule + "/service.Index"
+		if got := fmt.Sprint(instantiates(index, registry)); got != "[string *model.User]" {
+			t.Errorf("Index instantiates Registry with %s", got)
+		}
+		if got := fmt.Sprint(instantiates(layeredModule+"/service.NewIndex", layeredModule+"/model.NewRegistry")); got != "[string *model.User]" {
+			t.Errorf("NewIndex instantiates NewRegistry with %s", got)
+		}
+		if instantiates(registry+".Put", registry) != nil {
+			t.Error("generic receiver reported as instantiation")
+		}
+
+		if !hasEdge(graph, index, registry, "uses") {
+			t.Error("missing uses edge Index -> Registry")
+		}
+		if !hasEdge(graph, index+".Add", registry+".Put", "calls") {
+			t.Error("missing calls edge Index.Add -> Registry.Put")
+		}
+		if !hasEdge(graph, layeredModule+"/model.Sum", layeredModule+"/model.Number", "constrained-by") {
+			t.Error("missing constrained-by edge Sum -> Number")
+		}
+
+		if typeCheck {
+			if got := fmt.Sprint(instantiates(index+".Total", layeredModule+"/model.Sum")); got != "[int]" {
+				t.Errorf("Total instantiates Sum with %s", got)
+			}
+		}
+	})
+}
+
+// TestTypeKinds verifies that type declarations are classified by their
+// type expression and record their underlying type.
+func TestTypeKinds(t *testing.T) {
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph := analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck})
+
+		nodes := nodesByID(graph)
+		for _, node := range graph.Nodes {
+			if err := node.Validate(); err != nil {
+				t.Error(err)
+			}
+		}
+
+		tests := []struct {
+			name, entity, underlying string
+		}{
+			{"User", "struct", ""},
+			{"Reader", "interface", ""},
+			{"UserID", "alias", "string"},
+			{"Handler", "functype", "func(user *User) error"},
+			{"Users", "map", "map[UserID]*User"},
+			{"Names", "slice", "[]string"},
+			{"Window", "array", "[4]int"},
+			{"Events", "chan", "chan *User"},
+			{"UserRef", "pointer", "*User"},
+			{"Count", "basic", "int"},
+			{"Admin", "named", "User"},
+		}
+
+		for _, tt := range tests {
+			node := nodes[layeredModule+"/model."+tt.name]
+			if node.Entity != tt.entity || node.Underlying != tt.underlying {
+				t.Errorf("%s: got %s %q, want %s %q", tt.name, node.Entity, node.Underlying, tt.entity, tt.underlying)
+			}
+		}
+
+		for _, name := range []string{"Users", "Events", "UserRef", "Admin"} {
+			if !hasEdge(graph, layeredModule+"/model."+name, layeredModule+"/model.User", "uses") {
+				t.Errorf("missing uses edge %s -> User", name)
+			}
+		}
+	})
+
+	if err := (model.Node{ID: "x", Entity: "class"}).Validate(); err == nil {
+		t.Error("unknown entity accepted")
+	}
+}
+
+// TestNodeAttributes verifies source locations, docs, signatures and the
+// exported flag of components.
+func TestNodeAttributes(t *testing.T) {
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph := analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck})
+
+		nodes := nodesByID(graph)
+
+		rename := nodes[layeredModule+"/service.Service.Rename"]
+		if rename.File != "service/service.go" || rename.Line != 31 || rename.EndLine != 44 {
+			t.Errorf("Rename located at %s:%d-%d", rename.File, rename.Line, rename.EndLine)
+		}
+		if rename.Signature != "func (s *Service) Rename(id, name string) error" {
+			t.Errorf("Rename signature = %q", rename.Signature)
+		}
+		if rename.Doc != "Rename changes the name of a user." {
+			t.Errorf("Rename doc = %q", rename.Doc)
+		}
+		if rename.Exported == nil || !*rename.Exported {
+			t.Error("Rename not marked exported")
+		}
+
+		validate := nodes[layeredModule+"/service.validate"]
+		if validate.Exported == nil || *validate.Exported {
+			t.Error("validate not marked unexported")
+		}
+
+		service := nodes[layeredModule+"/service.Service"]
+		if service.Line != 15 || service.EndLine != 18 {
+			t.Errorf("Service spans lines %d-%d", service.Line, service.EndLine)
+		}
+
+		pkg := nodes[layeredModule+"/service"]
+		if pkg.Doc != "Package service implements user operations on top of a Repository." {
+			t.Errorf("package doc = %q", pkg.Doc)
+		}
+		if pkg.Exported != nil || pkg.File != "" {
+			t.Error("package has declaration attributes")
+		}
+	})
+}
+
+// TestSignatureEdges verifies accepts and returns edges from functions and
+// methods to the types in their signatures.
+func TestSignatureEdges(t *testing.T) {
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph := analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck})
+
+		tests := []struct {
+			from, to, edgeType string
+		}{
+			{layeredModule + "/service.New", layeredModule + "/model.Repository", "accepts"},
+			{layeredModule + "/service.New", layeredModule + "/service.Service", "returns"},
+			{layeredModule + "/service.Service.Rename", layeredModule + "/service.Service", "accepts"},
+			{layeredModule + "/store.MemoryStore.Get", layeredModule + "/model.User", "returns"},
+			{layeredModule + "/store.NewSeeded", layeredModule + "/model.User", "accepts"},
+			{layeredModule + "/model.Repository.Save", layeredModule + "/model.User", "accepts"},
+			{layeredModule + "/service.NewAudit", "example.com/extlib", "accepts"},
+		}
+
+		for _, tt := range tests {
+			if !hasEdge(graph, tt.from, tt.to, tt.edgeType) {
+				t.Errorf("missing %s edge %s -> %s", tt.edgeType, tt.from, tt.to)
+			}
+		}
+
+		if hasEdge(graph, layeredModule+"/model.Repository.Get", layeredModule+"/model.Repository", "accepts") {
+			t.Error("interface method accepts its own interface")
+		}
+	})
+}
+
+// TestVariablesAndConstants verifies var and const components and the
+// reads and writes edges of functions referencing them.
+func TestVariablesAndConstants(t *testing.T) {
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph := analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck})
+
+		nodes := nodesByID(graph)
+
+		tests := []struct {
+			id, entity string
+			mutable    bool
+		}{
+			{layeredModule + "/store.errNotFound", "var", false},
+			{layeredModule + "/store.saves", "var", true},
+			{layeredModule + "/store.MaxUsers", "const", false},
+		}
+
+		for _, tt := range tests {
+			node := nodes[tt.id]
+			if node.Entity != tt.entity || node.Mutable != tt.mutable {
+				t.Errorf("%s: got %s mutable=%v, want %s mutable=%v",
+					tt.id, node.Entity, node.Mutable, tt.entity, tt.mutable)
+			}
+		}
+
+		edges := []struct {
+			from, to, edgeType string
+		}{
+			{layeredModule + "/store.MemoryStore.Get", layeredModule + "/store.errNotFound", "reads"},
+			{layeredModule + "/store.MemoryStore.Save", layeredModule + "/store.saves", "writes"},
+			{layeredModule + "/store.Saves", layeredModule + "/store.saves", "reads"},
+			{layeredModule + "/service.Index.Capacity", layeredModule + "/store.MaxUsers", "reads"},
+			{layeredModule + "/store", layeredModule + "/store.saves", "contains"},
+		}
+
+		for _, tt := range edges {
+			if !hasEdge(graph, tt.from, tt.to, tt.edgeType) {
+				t.Errorf("missing %s edge %s -> %s", tt.edgeType, tt.from, tt.to)
+			}
+		}
+
+		if hasEdge(graph, layeredModule+"/store.MemoryStore.Save", layeredModule+"/store.saves", "reads") {
+			t.Error("increment reported as read")
+		}
+	})
+}
+
+// TestConcurrency verifies spawns and defers edges for go and defer
+// statements and sends and receives edges to channel fields and variables.
+func TestConcurrency(t *testing.T) {
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph := analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck})
+
+		nodes := nodesByID(graph)
+
+		if node := nodes[layeredModule+"/service.Pool.done"]; node.Entity != "field" || node.Underlying != "chan struct{}" {
+			t.Errorf("Pool.done: got entity %q underlying %q", node.Entity, node.Underlying)
+		}
+		if node := nodes[layeredModule+"/service.renames"]; node.Underlying != "chan *model.User" {
+			t.Errorf("renames: got underlying %q", node.Underlying)
+		}
+
+		pool := layeredModule + "/service.Pool"
+		edges := []struct {
+			from, to, edgeType string
+		}{
+			{pool + ".Start", pool + ".work", "spawns"},
+			{pool + ".Stop", pool + ".drain", "defers"},
+			{pool + ".work", pool + ".rename", "calls"},
+			{pool + ".work", layeredModule + "/service.renames", "receives"},
+			{pool + ".work", pool + ".done", "receives"},
+			{pool + ".drain", layeredModule + "/service.renames", "receives"},
+			{layeredModule + "/service.Enqueue", layeredModule + "/service.renames", "sends"},
+			{pool, pool + ".done", "contains"},
+		}
+
+		for _, tt := range edges {
+			if !hasEdge(graph, tt.from, tt.to, tt.edgeType) {
+				t.Errorf("missing %s edge %s -> %s", tt.edgeType, tt.from, tt.to)
+			}
+		}
+
+		if hasEdge(graph, pool+".Start", pool+".work", "calls") {
+			t.Error("go statement reported as call")
+		}
+		if hasEdge(graph, layeredModule+"/store.MemoryStore.Seed", layeredModule+"/store.MemoryStore.users", "receives") {
+			t.Error("range over a slice reported as receive")
+		}
+
+		view := analyzer.ConcurrencyView(graph)
+		for _, edge := range view.Edges {
+			if edge.Type == "calls" {
+				t.Errorf("concurrency view keeps calls edge %s -> %s", edge.From, edge.To)
+			}
+		}
+		if !hasEdge(view, pool+".Start", pool+".work", "spawns") {
+			t.Error("concurrency view drops spawns edge")
+		}
+	})
+}
+
+// TestFunctionReferences verifies references edges for functions and
+// methods used as values.
+func TestFunctionReferences(t *testing.T) {
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph := analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck})
+
+		service := layeredModule + "/service"
+		edges := []struct {
+			from, to, edgeType string
+		}{
+			{service + ".hooks", service + ".logChange", "references"},
+			{service + ".Reset", service + ".logChange", "references"},
+			{service + ".Pool.Watch", service + ".Pool.requeue", "references"},
+			{service + ".Pool.Watch", service + ".Register", "calls"},
+		}
+
+		for _, tt := range edges {
+			if !hasEdge(graph, tt.from, tt.to, tt.edgeType) {
+				t.Errorf("missing %s edge %s -> %s", tt.edgeType, tt.from, tt.to)
+			}
+		}
+
+		for _, edge := range graph.Edges {
+			if edge.Type == "references" && (edge.From == service+".Register" || edge.From == service+".Notify") {
+				t.Errorf("unexpected references edge %s -> %s", edge.From, edge.To)
+			}
+		}
+	})
+}
+
+// TestCallGraphAlgorithms verifies that SSA call graph algorithms project
+// their edges onto calls edges tagged with the algorithm, and that the
+// whole-program algorithms resolve calls through interfaces.
+func TestCallGraphAlgorithms(t *testing.T) {
+	service := layeredModule + "/service"
+	rename := service + ".Service.Rename"
+	save := layeredModule + "/store.MemoryStore.Save"
+
+	for _, tt := range []struct {
+		name string
+		opts analyzer.Options
+	}{
+		{analyzer.CallGraphStatic, analyzer.Options{CallGraph: analyzer.CallGraphStatic}},
+		{analyzer.CallGraphCHA, analyzer.Options{CallGraph: analyzer.CallGraphCHA}},
+		{analyzer.CallGraphRTA, analyzer.Options{CallGraph: analyzer.CallGraphRTA}},
+		{analyzer.CallGraphVTA, analyzer.Options{CallGraph: analyzer.CallGraphVTA}},
+		// The generated test mains must not replace the library roots.
+		{"rta with tests", analyzer.Options{CallGraph: analyzer.CallGraphRTA, IncludeTests: true}},
+	} {
+		algorithm := tt.opts.CallGraph
+		t.Run(tt.name, func(t *testing.T) {
+			graph := analyzeLayered(t, tt.opts)
+
+			found := make(map[string]bool)
+			for _, edge := range graph.Edges {
+				switch edge.Type {
+				case "calls", "spawns", "defers":
+					if edge.Algorithm != algorithm {
+						t.Errorf("%s edge %s -> %s has algorithm %q", edge.Type, edge.From, edge.To, edge.Algorithm)
+					}
+					found[edge.Type+" "+edge.From+" "+edge.To] = true
+				}
+			}
+
+			for _, key := range []string{
+				"calls " + service + ".NewDefault " + layeredModule + "/store.NewMemoryStore",
+				"calls " + rename + " " + service + ".validate",
+				"spawns " + service + ".Pool.Start " + service + ".Pool.work",
+				"defers " + service + ".Pool.Stop " + service + ".Pool.drain",
+			} {
+				if !found[key] {
+					t.Errorf("missing edge %s", key)
+				}
+			}
+
+			dynamic := found["calls "+rename+" "+save]
+			if dynamic != (algorithm != analyzer.CallGraphStatic) {
+				t.Errorf("interface call %s -> %s found: %v", rename, save, dynamic)
+			}
+		})
+	}
+
+	_, err := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{CallGraph: "pointer"}).
+		Analyze(filepath.Join("testdata", "layered"))
+	if err == nil {
+		t.Error("expected an error for an unknown algorithm")
+	}
+
+	// A module without packages has no RTA roots.
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/empty\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{CallGraph: analyzer.CallGraphRTA}).Analyze(dir); err != nil {
+		t.Errorf("rta on an empty module: %v", err)
+	}
+}
+
+// TestDeadCode verifies that only components unreachable from exported
+// API, tests and configured roots are reported.
+func TestDeadCode(t *testing.T) {
+	graph := analyzeLayered(t, analyzer.Options{IncludeTests: true, BodyTypes: true})
+
+	store := layeredModule + "/store"
+	service := layeredModule + "/service"
+
+	tests := []struct {
+		name  string
+		opts  analyzer.DeadCodeOptions
+		dead  []string
+		alive []string
+	}{
+		{
+			name:  "exported and tests",
+			opts:  analyzer.DeadCodeOptions{Exported: true, Tests: true},
+			dead:  []string{store + ".defaultDir", store + ".lockFile"},
+			alive: []string{service + ".Pool.work", service + ".Pool.requeue", store + ".MemoryStore", store + ".usage"},
+		},
+		{
+			name:  "tests only",
+			opts:  analyzer.DeadCodeOptions{Tests: true},
+			dead:  []string{service + ".Pool", service + ".Pool.work", store + ".defaultDir", store + ".usage"},
+			alive: []string{store + ".NewMemoryStore", store + ".MemoryStore.Seed", store + ".MemoryStore.Len"},
+		},
+		{
+			name:  "configured root",
+			opts:  analyzer.DeadCodeOptions{Exported: true, Tests: true, Roots: []string{store + ".lockFile"}},
+			alive: []string{store + ".lockFile", store + ".defaultDir"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dead := make(map[string]bool)
+			for _, node := range analyzer.FindDeadCode(graph, tt.opts) {
+				dead[node.ID] = true
+			}
+
+			for _, id := range tt.dead {
+				if !dead[id] {
+					t.Errorf("%s not reported", id)
+				}
+			}
+			for _, id := range tt.alive {
+				if dead[id] {
+					t.Errorf("%s reported as unreachable", id)
+				}
+			}
+			if tt.dead == nil && len(dead) > 0 {
+				t.Errorf("unexpected unreachable components: %v", dead)
+			}
+		})
+	}
+
+	analyzer.MarkUnreachable(graph, analyzer.FindDeadCode(graph, analyzer.DeadCodeOptions{Exported: true, Tests: true}))
+	for _, node := range graph.Nodes {
+		tagged := len(node.Tags) == 1 && node.Tags[0] == analyzer.UnreachableTag
+		if want := node.ID == store+".defaultDir" || node.ID == store+".lockFile"; tagged != want {
+			t.Errorf("%s: tags = %v", node.ID, node.Tags)
+		}
+	}
+}
+
+// TestBodyTypeEdges verifies that the types named in function bodies are
+// linked with mentions edges only when requested.
+func TestBodyTypeEdges(t *testing.T) {
+	activity := layeredModule + "/store.Activity"
+	usage := layeredModule + "/store.usage"
+
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		graph := analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck})
+		for _, edge := range graph.Edges {
+			if edge.Type == "mentions" || edge.From == activity && edge.To == usage {
+				t.Errorf("unexpected %s edge %s -> %s", edge.Type, edge.From, edge.To)
+			}
+		}
+
+		graph = analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck, BodyTypes: true})
+		if !hasEdge(graph, activity, usage, "mentions") {
+			t.Errorf("missing mentions edge %s -> %s", activity, usage)
+		}
+	})
+}
+
+// TestDeadCodeCallGraphs verifies that methods reachable only through the
+// calls found by an SSA call graph algorithm are not reported when tests
+// are analyzed as well.
+func TestDeadCodeCallGraphs(t *testing.T) {
+	service := layeredModule + "/service"
+
+	for _, algorithm := range []string{analyzer.CallGraphRTA, analyzer.CallGraphVTA} {
+		t.Run(algorithm, func(t *testing.T) {
+			graph := analyzeLayered(t, analyzer.Options{CallGraph: algorithm, IncludeTests: true, BodyTypes: true})
+
+			dead := make(map[string]bool)
+			for _, node := range analyzer.FindDeadCode(graph, analyzer.DeadCodeOptions{Exported: true, Tests: true}) {
+				dead[node.ID] = true
+			}
+
+			for _, id := range []string{service + ".Pool.work", service + ".Pool.drain", service + ".Pool.rename"} {
+				if dead[id] {
+					t.Errorf("%s reported as unreachable", id)
+				}
+			}
+		})
+	}
+}
+
+// TestImportDetail verifies that import edges list the symbols used from
+// the imported package only when import detail is requested.
+func TestImportDetail(t *testing.T) {
+	service := layeredModule + "/service"
+	store := layeredModule + "/store"
+
+	forEachMode(t, func(t *testing.T, typeCheck bool) {
+		for _, detail := range []bool{false, true} {
+			graph := analyzeLayered(t, analyzer.Options{TypeCheck: typeCheck, ImportDetail: detail})
+
+			var edge *model.Edge
+			for i := range graph.Edges {
+				if graph.Edges[i].From == service && graph.Edges[i].To == store && graph.Edges[i].Type == "import" {
+					edge = &graph.Edges[i]
+				}
+			}
+			if edge == nil {
+				t.Fatalf("missing import edge %s -> %s", service, store)
+			}
+
+			if !detail {
+				if edge.Symbols != nil || edge.Count != 0 {
+					t.Errorf("detail without --import-detail: %v, %d", edge.Symbols, edge.Count)
+				}
+				continue
+			}
+
+			want := []string{"MaxUsers", "MemoryStore", "NewMemoryStore"}
+			if !reflect.DeepEqual(edge.Symbols, want) {
+				t.Errorf("symbols = %v, want %v", edge.Symbols, want)
+			}
+			if edge.Count != 3 {
+				t.Errorf("count = %d, want 3", edge.Count)
+			}
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mshogin/archlint/internal/analyzer"
+	"github.com/mshogin/archlint/pkg/tracer"
+)
+
+const (
+	benchPackages     = 40
+	benchFilesPerPkg  = 25
+	benchFuncsPerFile = 20
+)
+
+// writeSyntheticModule generates a module with benchPackages packages of
+// benchFilesPerPkg files each. Every file declares a struct with methods
+// and functions that call into the previous package.
+func writeSyntheticModule(tb testing.TB) string {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/synthetic\n\ngo 1.22\n"), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+
+	for p := 0; p < benchPackages; p++ {
+		pkgDir := filepath.Join(dir, fmt.Sprintf("pkg%d", p))
+		if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+			tb.Fatal(err)
+		}
+
+		for f := 0; f < benchFilesPerPkg; f++ {
+			src := syntheticFile(p, f)
+			if err := os.WriteFile(filepath.Join(pkgDir, fmt.Sprintf("file%d.go", f)), []byte(src), 0o644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+
+	return dir
+}
+
+func syntheticFile(p, f int) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "package pkg%d\n\n", p)
+	if p > 0 {
+		fmt.Fprintf(&b, "import prev \"example.com/synthetic/pkg%d\"\n\n", p-1)
+	}
+
+	fmt.Fprintf(&b, "type Type%d struct {\n\tname string\n\tcount int\n}\n\n", f)
+
+	for i := 0; i < benchFuncsPerFile; i++ {
+		fmt.Fprintf(&b, "func (t *Type%d) Method%d(n int) int {\n\tif n > 0 {\n\t\treturn t.count + n\n\t}\n\treturn len(t.name)\n}\n\n", f, i)
+		fmt.Fprintf(&b, "func Func%d_%d(n int) int {\n\tt := &Type%d{}\n\tv := t.Method%d(n)\n", f, i, f, i)
+		if p > 0 {
+			fmt.Fprintf(&b, "\tv += prev.Func%d_%d(n)\n", f, i)
+		}
+		b.WriteString("\treturn v\n}\n\n")
+	}
+
+	return b.String()
+}
+
+func benchmarkAnalyze(b *testing.B, jobs int) {
+	dir := writeSyntheticModule(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{Jobs: jobs}).Analyze(dir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkAnalyzeSerial parses the synthetic module with a single worker.
+func BenchmarkAnalyzeSerial(b *testing.B) {
+	benchmarkAnalyze(b, 1)
+}
+
+// BenchmarkAnalyzeParallel parses the synthetic module with one worker per CPU.
+func BenchmarkAnalyzeParallel(b *testing.B) {
+	benchmarkAnalyze(b, 0)
+}
+
+// TestParallelParseDeterministic verifies that the worker count does not
+// change the collected graph.
+func TestParallelParseDeterministic(t *testing.T) {
+	dir := writeSyntheticModule(t)
+
+	count := func(jobs int) (int, int) {
+		graph, err := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{Jobs: jobs}).Analyze(dir)
+		if err != nil {
+			t.Fatalf("Analyze failed: %v", err)
+		}
+		return len(graph.Nodes), len(graph.Edges)
+	}
+
+	serialNodes, serialEdges := count(1)
+	parallelNodes, parallelEdges := count(8)
+
+	if serialNodes != parallelNodes || serialEdges != parallelEdges {
+		t.Errorf("serial %d/%d != parallel %d/%d nodes/edges",
+			serialNodes, serialEdges, parallelNodes, parallelEdges)
+	}
+}
+
+// TestParallelParseTraced verifies that a traced run records properly
+// nested enter and exit events when several workers are requested.
+func TestParallelParseTraced(t *testing.T) {
+	dir := writeSyntheticModule(t)
+
+	tracer.StartTrace("TestParallelParseTraced")
+	_, err := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{Jobs: 8}).Analyze(dir)
+	trace := tracer.StopTrace()
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	var stack []string
+	for _, call := range trace.Calls {
+		if call.Event == "enter" {
+			stack = append(stack, call.Function)
+			continue
+		}
+		if len(stack) == 0 || stack[len(stack)-1] != call.Function {
+			t.Fatalf("%s of %s does not match the open calls %v", call.Event, call.Function, stack)
+		}
+		stack = stack[:len(stack)-1]
+	}
+}
+
+// BenchmarkAnalyzeCached re-analyzes the synthetic module with a warm
+// analysis cache.
+func BenchmarkAnalyzeCached(b *testing.B) {
+	dir := writeSyntheticModule(b)
+	opts := analyzer.Options{CacheDir: filepath.Join(b.TempDir(), "cache")}
+
+	if _, err := analyzer.NewGoAnalyzerWithOptions(opts).Analyze(dir); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := analyzer.NewGoAnalyzerWithOptions(opts).Analyze(dir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mshogin/archlint/internal/analyzer"
+	"github.com/mshogin/archlint/internal/model"
+)
+
+func hasNode(graph *model.Graph, id string) bool {
+	for _, node := range graph.Nodes {
+		if node.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TestAnalysisCache verifies that cached re-runs produce the same graph and
+// that changed files are parsed again.
+func TestAnalysisCache(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, ".archlint", "cache")
+
+	write := func(name, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("go.mod", "module example.com/cached\n\ngo 1.22\n")
+	write("a.go", "package cached\n\nfunc A() { B() }\n")
+	write("b.go", "package cached\n\nfunc B() {}\n")
+
+	analyze := func() *model.Graph {
+		t.Helper()
+		graph, err := analyzer.NewGoAnalyzerWithOptions(analyzer.Options{CacheDir: cacheDir}).Analyze(dir)
+		if err != nil {
+			t.Fatalf("Analyze failed: %v", err)
+		}
+		return graph
+	}
+
+	first := analyze()
+
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*", "*.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d cache entries, want 2", len(entries))
+	}
+
+	second := analyze()
+	if len(first.Nodes) != len(second.Nodes) || len(first.Edges) != len(second.Edges) {
+		t.Errorf("cached run %d/%d != first run %d/%d nodes/edges",
+			len(second.Nodes), len(second.Edges), len(first.Nodes), len(first.Edges))
+	}
+	if !hasEdge(second, "example.com/cached.A", "example.com/cached.B", "calls") {
+		t.Error("cached run lost the calls edge A -> B")
+	}
+
+	write("b.go", "package cached\n\nfunc B() { C() }\n\nfunc C() {}\n")
+
+	third := analyze()
+	if !hasNode(third, "example.com/cached.C") {
+		t.Error("changed file was not parsed again")
+	}
+	if !hasEdge(third, "example.com/cached.B", "example.com/cached.C", "calls") {
+		t.Error("missing calls edge B -> C after change")
+	}
+
+	removed, err := analyzer.PruneCache(cacheDir, time.Hour)
+	if err != nil {
+		t.Fatalf("PruneCache failed: %v", err)
+	}
+	if removed != 0 {
+		t.Errorf("pruned %d recently used entries", removed)
+	}
+
+	removed, err = analyzer.PruneCache(cacheDir, 0)
+	if err != nil {
+		t.Fatalf("PruneCache failed: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("pruned %d entries, want 3", removed)
+	}
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mshogin/archlint/internal/model"
+)
+
+// shuffled returns a copy of graph with components and links in reverse
+// order.
+func shuffled(graph *model.Graph) *model.Graph {
+	out := &model.Graph{}
+	for i := len(graph.Nodes) - 1; i >= 0; i-- {
+		out.Nodes = append(out.Nodes, graph.Nodes[i])
+	}
+	for i := len(graph.Edges) - 1; i >= 0; i-- {
+		out.Edges = append(out.Edges, graph.Edges[i])
+	}
+
+	return out
+}
+
+func TestDiffIgnoresOrder(t *testing.T) {
+	if diff := model.Diff(indexGraph(), shuffled(indexGraph())); !diff.Empty() || diff.NewDependencies != nil {
+		t.Errorf("diff of reordered graph: %+v", diff)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	updated := shuffled(indexGraph())
+
+	// store.Put becomes a method, svc.Run is removed, cache.Load is added
+	// and called from app.main, which also calls store.Get directly now.
+	var nodes []model.Node
+	for _, node := range updated.Nodes {
+		switch node.ID {
+		case "svc.Run":
+			continue
+		case "store.Put":
+			node.Entity = "method"
+		}
+		nodes = append(nodes, node)
+	}
+	updated.Nodes = append(nodes,
+		model.Node{ID: "cache", Entity: "package"},
+		model.Node{ID: "cache.Load", Entity: "function"},
+	)
+
+	var edges []model.Edge
+	for _, edge := range updated.Edges {
+		if edge.From != "svc.Run" && edge.To != "svc.Run" {
+			edges = append(edges, edge)
+		}
+	}
+	updated.Edges = append(edges,
+		model.Edge{From: "cache", To: "cache.Load", Type: "contains"},
+		model.Edge{From: "app.main", To: "cache.Load", Type: "calls"},
+		model.Edge{From: "app.main", To: "store.Get", Type: "calls"},
+		model.Edge{From: "svc", To: "store", Type: "import"},
+	)
+
+	diff := model.Diff(indexGraph(), updated)
+
+	ids := func(nodes []model.Node) []string {
+		var out []string
+		for _, node := range nodes {
+			out = append(out, node.ID)
+		}
+		return out
+	}
+	links := func(edges []model.Edge) []string {
+		var out []string
+		for _, edge := range edges {
+			out = append(out, edge.Type+" "+edge.From+" -> "+edge.To)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"added", ids(diff.AddedNodes), []string{"cache", "cache.Load"}},
+		{"removed", ids(diff.RemovedNodes), []string{"svc.Run"}},
+		{"changed", diff.ChangedNodes, []model.NodeChange{{ID: "store.Put", OldEntity: "function", NewEntity: "method"}}},
+		{"added links", links(diff.AddedEdges), []string{
+			"calls app.main -> cache.Load",
+			"calls app.main -> store.Get",
+			"contains cache -> cache.Load",
+		}},
+		{"removed links", links(diff.RemovedEdges), []string{
+			"calls app.main -> svc.Run",
+			"calls svc.Run -> store.Get",
+			"calls svc.Run -> store.Put",
+			"contains svc -> svc.Run",
+		}},
+		{"link types", diff.EdgeTypes(), []string{"calls", "contains"}},
+		{"new dependencies", diff.NewDependencies, []model.Dependency{
+			{From: "app", To: "cache"},
+			{From: "app", To: "store"},
+		}},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
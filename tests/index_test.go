@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mshogin/archlint/internal/model"
+)
+
+// indexGraph is a small graph: app.main calls svc.Run, which calls
+// store.Get and store.Put; store.Put calls store.Get; svc imports store.
+func indexGraph() *model.Graph {
+	return &model.Graph{
+		Nodes: []model.Node{
+			{ID: "app", Entity: "package"},
+			{ID: "app.main", Entity: "function"},
+			{ID: "svc", Entity: "package"},
+			{ID: "svc.Run", Entity: "function"},
+			{ID: "store", Entity: "package"},
+			{ID: "store.Get", Entity: "function"},
+			{ID: "store.Put", Entity: "function"},
+		},
+		Edges: []model.Edge{
+			{From: "app", To: "app.main", Type: "contains"},
+			{From: "svc", To: "svc.Run", Type: "contains"},
+			{From: "store", To: "store.Get", Type: "contains"},
+			{From: "store", To: "store.Put", Type: "contains"},
+			{From: "app.main", To: "svc.Run", Type: "calls"},
+			{From: "svc.Run", To: "store.Get", Type: "calls"},
+			{From: "svc.Run", To: "store.Put", Type: "calls"},
+			{From: "store.Put", To: "store.Get", Type: "calls"},
+			{From: "svc", To: "store", Type: "import"},
+		},
+	}
+}
+
+func TestIndexLookup(t *testing.T) {
+	ix := model.NewIndex(indexGraph())
+
+	if node, ok := ix.Node("svc.Run"); !ok || node.Entity != "function" {
+		t.Errorf("Node(svc.Run) = %+v, %v", node, ok)
+	}
+	if _, ok := ix.Node("missing"); ok {
+		t.Error("Node(missing) found")
+	}
+
+	if got := ix.OutEdges("svc.Run", "calls"); len(got) != 2 || got[0].To != "store.Get" || got[1].To != "store.Put" {
+		t.Errorf("OutEdges(svc.Run, calls) = %v", got)
+	}
+	if got := ix.InEdges("store.Get", "contains"); len(got) != 1 || got[0].From != "store" {
+		t.Errorf("InEdges(store.Get, contains) = %v", got)
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"successors", ix.Successors("svc.Run"), []string{"store.Get", "store.Put"}},
+		{"predecessors", ix.Predecessors("store.Get", "calls"), []string{"store.Put", "svc.Run"}},
+		{"neighbors", ix.Neighbors("store.Put"), []string{"store", "store.Get", "svc.Run"}},
+		{"dependencies", ix.Dependencies("app.main", 0, "calls"), []string{"store.Get", "store.Put", "svc.Run"}},
+		{"dependencies depth", ix.Dependencies("app.main", 1, "calls"), []string{"svc.Run"}},
+		{"dependents", ix.Dependents("store.Get", 0, "calls"), []string{"app.main", "store.Put", "svc.Run"}},
+		{"dependents depth", ix.Dependents("store.Get", 1), []string{"store", "store.Put", "svc.Run"}},
+		{"reverse", ix.Reverse().Dependencies("store.Get", 0, "calls"), []string{"app.main", "store.Put", "svc.Run"}},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestIndexShortestPath(t *testing.T) {
+	ix := model.NewIndex(indexGraph())
+
+	path := ix.ShortestPath("app.main", "store.Get", "calls")
+	var hops []string
+	for _, edge := range path {
+		hops = append(hops, edge.From+"->"+edge.To)
+	}
+	if want := []string{"app.main->svc.Run", "svc.Run->store.Get"}; !reflect.DeepEqual(hops, want) {
+		t.Errorf("path = %v, want %v", hops, want)
+	}
+
+	if path := ix.ShortestPath("store.Get", "app.main", "calls"); path != nil {
+		t.Errorf("unexpected path %v", path)
+	}
+	if path := ix.ShortestPath("app", "store.Get", "contains"); path != nil {
+		t.Errorf("unexpected path across edge types %v", path)
+	}
+}
+
+func TestIndexSubgraph(t *testing.T) {
+	ix := model.NewIndex(indexGraph())
+
+	sub := ix.Subgraph("store.*", "svc.Run")
+
+	var ids []string
+	for _, node := range sub.Nodes {
+		ids = append(ids, node.ID)
+	}
+	if want := []string{"svc.Run", "store.Get", "store.Put"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("nodes = %v, want %v", ids, want)
+	}
+	if len(sub.Edges) != 3 {
+		t.Errorf("edges = %v, want the 3 calls between the kept nodes", sub.Edges)
+	}
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mshogin/archlint/internal/model"
+)
+
+func TestQuery(t *testing.T) {
+	ix := model.NewIndex(indexGraph())
+
+	tests := []struct {
+		expr  string
+		nodes []string
+		edges int
+	}{
+		{"callers(store.Get)", []string{"app.main", "store.Put", "svc.Run"}, 4},
+		{"callers(Get) depth 1", []string{"store.Put", "svc.Run"}, 3},
+		{"callees(app.main) match store.*", []string{"store.Get", "store.Put"}, 1},
+		{"deps(svc)", []string{"store"}, 1},
+		{"rdeps(store.Get) via calls, contains where entity=package", []string{"app", "store", "svc"}, 1},
+		{"nodes(store.*, app)", []string{"app", "store.Get", "store.Put"}, 0},
+		{"path(app.main, Get)", []string{"app.main", "svc.Run", "store.Get"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			query, err := model.ParseQuery(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseQuery failed: %v", err)
+			}
+
+			result, err := query.Run(ix)
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+
+			var ids []string
+			for _, node := range result.Nodes {
+				ids = append(ids, node.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.nodes) {
+				t.Errorf("nodes = %v, want %v", ids, tt.nodes)
+			}
+			if len(result.Graph.Edges) != tt.edges {
+				t.Errorf("edges = %v, want %d", result.Graph.Edges, tt.edges)
+			}
+		})
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	ix := model.NewIndex(indexGraph())
+
+	for _, expr := range []string{
+		"",
+		"who(store.Get)",
+		"callers store.Get",
+		"callers(store.Get",
+		"callers(store.Get, svc.Run)",
+		"callers(store.Get) depth 0",
+		"callers(store.Get) where kind=function",
+		"path(app.main) ",
+		"path(app.main, store.Get) depth 2",
+		"nodes()",
+		"callers(store.Get) sorted",
+	} {
+		if _, err := model.ParseQuery(expr); !errors.Is(err, model.ErrQuerySyntax) {
+			t.Errorf("ParseQuery(%q) = %v, want a syntax error", expr, err)
+		}
+	}
+
+	for expr, want := range map[string]error{
+		"callers(missing)":          model.ErrNoComponent,
+		"path(store.Get, app.main)": model.ErrNoPath,
+	} {
+		query, err := model.ParseQuery(expr)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q) failed: %v", expr, err)
+		}
+		if _, err := query.Run(ix); !errors.Is(err, want) {
+			t.Errorf("Run(%q) = %v, want %v", expr, err, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mshogin/archlint/internal/model"
+)
+
+// TestLoadGraphMigratesVersion1 loads a graph written before files carried
+// a schema version.
+func TestLoadGraphMigratesVersion1(t *testing.T) {
+	graph, err := model.LoadGraph(filepath.Join("..", "arch", "architecture.yaml"))
+	if err != nil {
+		t.Fatalf("LoadGraph failed: %v", err)
+	}
+
+	if graph.Schema != model.SchemaVersion {
+		t.Errorf("schema = %d, want %d", graph.Schema, model.SchemaVersion)
+	}
+	if graph.Metadata != nil {
+		t.Errorf("unexpected metadata %+v", graph.Metadata)
+	}
+	if len(graph.Nodes) == 0 || len(graph.Edges) == 0 {
+		t.Errorf("empty graph: %d components, %d links", len(graph.Nodes), len(graph.Edges))
+	}
+}
+
+func TestLoadGraphFormats(t *testing.T) {
+	exported := true
+	graph := &model.Graph{
+		Schema: model.SchemaVersion,
+		Metadata: &model.Metadata{
+			ArchlintVersion: "0.1.0",
+			Module:          "example.com/app",
+			Revision:        "0123456789abcdef",
+			GeneratedAt:     "2026-01-02T03:04:05Z",
+			Options:         map[string]string{"typecheck": "true"},
+		},
+		Nodes: []model.Node{
+			{ID: "example.com/app", Title: "app", Entity: "package"},
+			{ID: "example.com/app.Run", Title: "Run", Entity: "function", Exported: &exported},
+		},
+		Edges: []model.Edge{
+			{From: "example.com/app", To: "example.com/app.Run", Type: "contains"},
+		},
+	}
+
+	yamlData, err := yaml.Marshal(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{"graph.yaml": yamlData, "graph.json": jsonData} {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := model.LoadGraph(filename)
+		if err != nil {
+			t.Fatalf("LoadGraph(%s) failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(loaded, graph) {
+			t.Errorf("%s: loaded %+v, want %+v", name, loaded, graph)
+		}
+	}
+}
+
+func TestLoadGraphErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		want error
+	}{
+		{"schema: 99\ncomponents: []\nlinks: []\n", model.ErrUnsupportedSchema},
+		{`{"schema": 99, "components": [], "links": []}`, model.ErrUnsupportedSchema},
+		{"components: {", model.ErrGraphRead},
+		{`{"components": [`, model.ErrGraphRead},
+	}
+
+	for _, tt := range tests {
+		if _, err := model.DecodeGraph([]byte(tt.data)); !errors.Is(err, tt.want) {
+			t.Errorf("DecodeGraph(%q) = %v, want %v", tt.data, err, tt.want)
+		}
+	}
+
+	if _, err := model.LoadGraph(filepath.Join(t.TempDir(), "missing.yaml")); !errors.Is(err, model.ErrGraphRead) {
+		t.Errorf("LoadGraph(missing) = %v, want %v", err, model.ErrGraphRead)
+	}
+}
@@ -0,0 +1,12 @@
+// Package textutil is a third-party dependency of the layered sample.
+package textutil
+
+import "strings"
+
+// Normalize trims surrounding whitespace.
+func Normalize(s string) string {
+	return strings.TrimSpace(s)
+}
+
+// Normalizer normalizes strings.
+type Normalizer struct{}
@@ -0,0 +1,7 @@
+module example.com/layered
+
+go 1.22
+
+require example.com/extlib v1.2.0
+
+replace example.com/extlib => ../extlib
@@ -0,0 +1,28 @@
+package model
+
+// UserID identifies a User.
+type UserID = string
+
+// Handler processes a user.
+type Handler func(user *User) error
+
+// Users indexes users by ID.
+type Users map[UserID]*User
+
+// Names lists user names.
+type Names []string
+
+// Window holds the last four login counts.
+type Window [4]int
+
+// Events streams user changes.
+type Events chan *User
+
+// UserRef points to a user.
+type UserRef *User
+
+// Count counts users.
+type Count int
+
+// Admin is a user with elevated rights.
+type Admin User
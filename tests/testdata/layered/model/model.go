@@ -0,0 +1,35 @@
+// Package model defines the domain types of the layered sample.
+package model
+
+// User is a registered user.
+type User struct {
+	ID   string
+	Name string
+}
+
+// Repository stores users.
+type Repository interface {
+	Get(id string) (*User, error)
+	Save(user *User) error
+}
+
+// Reader reads users.
+type Reader interface {
+	Get(id string) (*User, error)
+}
+
+// Cache is a Reader that knows its size.
+type Cache interface {
+	Reader
+	Len() int
+}
+
+// Directory looks users up through a Repository.
+type Directory struct {
+	repo Repository
+}
+
+// Lookup returns the user with the given ID.
+func (d *Directory) Lookup(id string) (*User, error) {
+	return d.repo.Get(id)
+}
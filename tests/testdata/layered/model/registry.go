@@ -0,0 +1,36 @@
+package model
+
+// Number is the constraint of numeric counters.
+type Number interface {
+	~int | ~int64
+}
+
+// Registry is a generic keyed collection.
+type Registry[K comparable, V any] struct {
+	items map[K]V
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry[K comparable, V any]() *Registry[K, V] {
+	return &Registry[K, V]{items: make(map[K]V)}
+}
+
+// Put stores v under k.
+func (r *Registry[K, V]) Put(k K, v V) {
+	r.items[k] = v
+}
+
+// Lookup returns the value stored under k.
+func (r *Registry[K, V]) Lookup(k K) (V, bool) {
+	v, ok := r.items[k]
+	return v, ok
+}
+
+// Sum adds up values.
+func Sum[T Number](values []T) T {
+	var total T
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
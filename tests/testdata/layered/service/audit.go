@@ -0,0 +1,17 @@
+package service
+
+import (
+	tu "example.com/extlib/textutil"
+	. "example.com/layered/model"
+)
+
+// Audit records renamed users.
+type Audit struct {
+	Users      []*User
+	Normalizer tu.Normalizer
+}
+
+// NewAudit returns an Audit that normalizes names with n.
+func NewAudit(n tu.Normalizer) *Audit {
+	return &Audit{Normalizer: n}
+}
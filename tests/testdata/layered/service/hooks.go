@@ -0,0 +1,39 @@
+package service
+
+import "example.com/layered/model"
+
+// Hook runs after a user changed.
+type Hook func(user *model.User)
+
+// hooks are run by Notify.
+var hooks = []Hook{logChange}
+
+func logChange(user *model.User) {}
+
+// Register adds hook to the hooks run by Notify.
+func Register(hook Hook) {
+	hooks = append(hooks, hook)
+}
+
+// Reset restores the default hooks.
+func Reset() {
+	var first Hook
+	first = logChange
+	hooks = []Hook{first}
+}
+
+// Notify runs every hook for user.
+func Notify(user *model.User) {
+	for _, hook := range hooks {
+		hook(user)
+	}
+}
+
+// Watch requeues users changed after the pool started.
+func (p *Pool) Watch() {
+	Register(p.requeue)
+}
+
+func (p *Pool) requeue(user *model.User) {
+	Enqueue(user)
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"example.com/layered/model"
+	"example.com/layered/store"
+)
+
+// Index finds users by name.
+type Index struct {
+	byName *model.Registry[string, *model.User]
+}
+
+// NewIndex returns an empty Index.
+func NewIndex() *Index {
+	return &Index{byName: model.NewRegistry[string, *model.User]()}
+}
+
+// Add indexes user under its name.
+func (i *Index) Add(user *model.User) {
+	i.byName.Put(user.Name, user)
+}
+
+// Total sums counters with an inferred instantiation of model.Sum.
+func (i *Index) Total(counts []int) int {
+	return model.Sum(counts)
+}
+
+// Capacity returns the number of users an Index is planned for.
+func (i *Index) Capacity() int {
+	return store.MaxUsers
+}
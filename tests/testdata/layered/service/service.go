@@ -0,0 +1,52 @@
+// Package service implements user operations on top of a Repository.
+package service
+
+import (
+	"errors"
+
+	"example.com/extlib/textutil"
+	"example.com/layered/model"
+	"example.com/layered/store"
+)
+
+var errEmptyName = errors.New("empty name")
+
+// Service renames users.
+type Service struct {
+	repo  model.Repository
+	cache *store.MemoryStore
+}
+
+// New creates a Service backed by repo.
+func New(repo model.Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// NewDefault creates a Service backed by an in-memory store.
+func NewDefault() *Service {
+	return New(store.NewMemoryStore())
+}
+
+// Rename changes the name of a user.
+func (s *Service) Rename(id, name string) error {
+	if err := validate(name); err != nil {
+		return err
+	}
+
+	user, err := s.repo.Get(id)
+	if err != nil {
+		return err
+	}
+
+	user.Name = textutil.Normalize(name)
+
+	return s.repo.Save(user)
+}
+
+func validate(name string) error {
+	if name == "" {
+		return errEmptyName
+	}
+
+	return nil
+}
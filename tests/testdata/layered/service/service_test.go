@@ -0,0 +1,13 @@
+package service_test
+
+import (
+	"fmt"
+
+	"example.com/layered/service"
+)
+
+func ExampleService_Rename() {
+	svc := service.NewDefault()
+	fmt.Println(svc.Rename("1", "Alice") != nil)
+	// Output: true
+}
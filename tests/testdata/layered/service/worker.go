@@ -0,0 +1,57 @@
+package service
+
+import "example.com/layered/model"
+
+// renames queues users waiting to be renamed.
+var renames = make(chan *model.User, 16)
+
+// Pool renames queued users on background goroutines.
+type Pool struct {
+	svc  *Service
+	done chan struct{}
+}
+
+// NewPool creates a Pool working for svc.
+func NewPool(svc *Service) *Pool {
+	return &Pool{svc: svc, done: make(chan struct{})}
+}
+
+// Start starts n workers.
+func (p *Pool) Start(n int) {
+	for i := 0; i < n; i++ {
+		go p.work()
+	}
+}
+
+// Stop stops the workers and renames the users still queued.
+func (p *Pool) Stop() {
+	defer p.drain()
+	close(p.done)
+}
+
+func (p *Pool) work() {
+	for {
+		select {
+		case user := <-renames:
+			p.rename(user)
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *Pool) drain() {
+	close(renames)
+	for user := range renames {
+		p.rename(user)
+	}
+}
+
+func (p *Pool) rename(user *model.User) {
+	_ = p.svc.Rename(user.ID, user.Name)
+}
+
+// Enqueue queues user for renaming.
+func Enqueue(user *model.User) {
+	renames <- user
+}
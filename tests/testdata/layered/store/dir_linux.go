@@ -0,0 +1,11 @@
+package store
+
+// defaultDir returns the data directory on Linux.
+func defaultDir() string {
+	return "/var/lib/layered"
+}
+
+// lockFile returns the path of the lock file.
+func lockFile() string {
+	return defaultDir() + "/lock"
+}
@@ -0,0 +1,6 @@
+package store
+
+// defaultDir returns the data directory on Windows.
+func defaultDir() string {
+	return `C:\ProgramData\layered`
+}
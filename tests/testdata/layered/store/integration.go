@@ -0,0 +1,8 @@
+//go:build integration
+
+package store
+
+// integrationDir returns the data directory used by integration runs.
+func integrationDir() string {
+	return defaultDir() + "/integration"
+}
@@ -0,0 +1,61 @@
+// Package store provides Repository implementations.
+package store
+
+import (
+	"errors"
+
+	"example.com/layered/model"
+)
+
+var errNotFound = errors.New("user not found")
+
+// MemoryStore keeps users in memory.
+type MemoryStore struct {
+	users map[string]*model.User
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{users: make(map[string]*model.User)}
+}
+
+// Get returns the user with the given ID.
+func (s *MemoryStore) Get(id string) (*model.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, errNotFound
+	}
+
+	return user, nil
+}
+
+// Save stores the user.
+func (s *MemoryStore) Save(user *model.User) error {
+	s.users[user.ID] = user
+	saves++
+	return nil
+}
+
+// Len returns the number of stored users.
+func (s *MemoryStore) Len() int {
+	return len(s.users)
+}
+
+// Seed stores all given users.
+func (s *MemoryStore) Seed(users ...*model.User) error {
+	for _, user := range users {
+		if err := s.Save(user); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewSeeded creates a MemoryStore holding users.
+func NewSeeded(users ...*model.User) (*MemoryStore, error) {
+	store := NewMemoryStore()
+	err := store.Seed(users...)
+
+	return store, err
+}
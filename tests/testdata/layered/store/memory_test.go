@@ -0,0 +1,33 @@
+package store
+
+import (
+	"testing"
+
+	"example.com/layered/model"
+)
+
+func newSeededStore(t *testing.T) *MemoryStore {
+	t.Helper()
+
+	s := NewMemoryStore()
+	if err := s.Seed(&model.User{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestGet(t *testing.T) {
+	s := newSeededStore(t)
+
+	if _, err := s.Get("1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkLen(b *testing.B) {
+	s := NewMemoryStore()
+	for i := 0; i < b.N; i++ {
+		s.Len()
+	}
+}
@@ -0,0 +1,23 @@
+package store
+
+// MaxUsers is the capacity planned for a MemoryStore.
+const MaxUsers = 1000
+
+// saves counts the users saved by all stores.
+var saves int
+
+// Saves returns the number of users saved so far.
+func Saves() int {
+	return saves
+}
+
+// usage is a snapshot of the store activity.
+type usage struct {
+	saves int
+}
+
+// Activity returns the number of users saved so far.
+func Activity() int {
+	current := usage{saves: saves}
+	return current.saves
+}
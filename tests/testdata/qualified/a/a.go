@@ -0,0 +1,14 @@
+package a
+
+// User is the user of package a.
+type User struct{}
+
+// Repo loads users of package a.
+type Repo interface {
+	Get(id string) (*User, error)
+}
+
+// Sink accepts any value.
+type Sink interface {
+	Put(v any)
+}
@@ -0,0 +1,19 @@
+package b
+
+import "example.com/qualified/a"
+
+// User has the same name as a.User but is a different type.
+type User struct{}
+
+// Store returns its own User, so it is not an a.Repo, and accepts
+// interface{}, which is the same type as any.
+type Store struct{}
+
+func (s *Store) Get(id string) (*User, error) { return nil, nil }
+
+func (s *Store) Put(v interface{}) {}
+
+// Repo returns the User of package a.
+type Repo struct{}
+
+func (r Repo) Get(id string) (*a.User, error) { return nil, nil }
@@ -0,0 +1,6 @@
+package tools
+
+// Generate is a nested module that is not part of the workspace.
+func Generate() string {
+	return "generated"
+}
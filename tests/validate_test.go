@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mshogin/archlint/internal/analyzer"
+	"github.com/mshogin/archlint/internal/model"
+)
+
+const brokenGraph = `schema: 2
+components:
+  - id: app
+    title: app
+    entity: package
+  - id: app
+    title: app
+    entity: packge
+  - id: app.Run
+    title: Run
+    entity: function
+  - title: nameless
+    entity: function
+links:
+  - from: app
+    to: app.Run
+    type: contains
+  - from: app.Run
+    to: app
+    type: contains
+  - from: app.Run
+    to: app.Missing
+    type: call
+`
+
+func TestValidateFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "architecture.yaml")
+	if err := os.WriteFile(filename, []byte(brokenGraph), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := model.ValidateFile(filename)
+	if err != nil {
+		t.Fatalf("ValidateFile failed: %v", err)
+	}
+
+	var got []string
+	for _, problem := range problems {
+		got = append(got, problem.String())
+	}
+
+	want := []string{
+		`6: components[1]: duplicate id app, first declared at components[0]`,
+		`6: components[1]: unknown entity "packge" of component app`,
+		`12: components[3]: component without id`,
+		`21: links[2]: unknown link type "call" of link app.Run -> app.Missing`,
+		`21: links[2]: to refers to unknown component "app.Missing"`,
+		`18: links[1]: contains cycle app -> app.Run -> app`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestValidateCollectedGraph(t *testing.T) {
+	for _, opts := range []analyzer.Options{
+		{},
+		{IncludeTests: true, ImportDetail: true},
+		{TypeCheck: true, IncludeTests: true},
+	} {
+		graph := analyzeLayered(t, opts)
+		if problems := graph.Validate(); len(problems) > 0 {
+			t.Errorf("%+v: collected graph has problems: %v", opts, problems)
+		}
+	}
+
+	if problems := indexGraph().Validate(); len(problems) > 0 {
+		t.Errorf("index graph has problems: %v", problems)
+	}
+}